@@ -0,0 +1,57 @@
+// Команда deeplink печатает подписанную ссылку на бота с зашитыми настройками:
+//
+//	go run ./cmd/deeplink -bot MyAlertsBot -mode intraday -metric o -threshold 2.5 -ref chan1
+//
+// Секрет берётся из deep_link_secret того же конфига, что читает бот.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"1333/internal/bots"
+)
+
+func main() {
+	var l bots.DeepLink
+	configPath := flag.String("config", "configs/config.json", "путь к конфигу бота")
+	username := flag.String("bot", "", "username основного бота без @")
+	flag.StringVar(&l.Mode, "mode", "scalp", "режим: scalp, intraday или spot")
+	flag.StringVar(&l.Metric, "metric", "p", "метрика: p цена, o OI, v объём на споте, f funding")
+	flag.Float64Var(&l.Threshold, "threshold", 0, "порог метрики")
+	flag.StringVar(&l.TimeFrame, "tf", "", "таймфрейм, например 5m")
+	flag.StringVar(&l.TargetBot, "target", "", "бот для алертов из additional_bots")
+	flag.StringVar(&l.Referral, "ref", "", "реферальный код")
+	flag.Parse()
+
+	if *username == "" {
+		log.Fatal("Не задан -bot")
+	}
+	secret, err := loadSecret(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	payload, err := l.Encode(secret)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("https://t.me/%s?start=%s\n", *username, payload)
+}
+
+func loadSecret(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var c struct {
+		DeepLinkSecret string `json:"deep_link_secret"`
+	}
+	if err := json.NewDecoder(f).Decode(&c); err != nil {
+		return nil, err
+	}
+	return []byte(c.DeepLinkSecret), nil
+}
@@ -4,19 +4,214 @@ package bots
 import (
 	"fmt"
 	"log"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
+
+	"1333/internal/exchanges"
+	"1333/internal/rules"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 type UserSettings struct {
-	ChangeThreshold float64 `json:"change_threshold"`
-	TimeFrame       string  `json:"time_frame"`
-	TargetBot       string  `json:"target_bot"`
-	MonitorOI       bool    `json:"monitor_oi"`
-	OIThreshold     float64 `json:"oi_threshold"`
+	Mode               string       `json:"mode,omitempty"` // scalp, intraday или spot
+	ChangeThreshold    float64      `json:"change_threshold"`
+	TimeFrame          string       `json:"time_frame"`
+	TargetBot          string       `json:"target_bot"`
+	MonitorOI          bool         `json:"monitor_oi"`
+	OIThreshold        float64      `json:"oi_threshold"`
+	OIWindows          []int        `json:"oi_windows,omitempty"`        // окна сравнения OI в минутах
+	VolumeThreshold    float64      `json:"volume_threshold,omitempty"`  // объём свечи в USDT
+	VolumeMultiplier   float64      `json:"volume_multiplier,omitempty"` // во сколько раз объём свечи выше среднего
+	VolumeTimeFrame    string       `json:"volume_time_frame,omitempty"`
+	FundingThreshold   float64      `json:"funding_threshold,omitempty"` // модуль ставки финансирования в %
+	FundingFlipAlert   bool         `json:"funding_flip_alert,omitempty"`
+	LiqThreshold       float64      `json:"liq_threshold,omitempty"` // сумма ликвидаций в USDT
+	LiqWindow          int          `json:"liq_window,omitempty"`    // окно в минутах
+	RatioMetric        string       `json:"ratio_metric,omitempty"`  // long_short или taker
+	RatioCross         float64      `json:"ratio_cross,omitempty"`   // уровень, пересечение которого вызывает алерт
+	RatioShift         float64      `json:"ratio_shift,omitempty"`   // изменение соотношения в % за RatioWindow
+	RatioWindow        int          `json:"ratio_window,omitempty"`  // окно в минутах
+	PreferredExchanges []string     `json:"preferred_exchanges,omitempty"`
+	Rules              []rules.Rule `json:"rules,omitempty"`            // составные условия
+	Watchlist          []string     `json:"watchlist,omitempty"`        // если не пуст, алерты только по этим тикерам
+	Blacklist          []string     `json:"blacklist,omitempty"`        // тикеры, по которым алерты не нужны
+	MinQuoteVolume     float64      `json:"min_quote_volume,omitempty"` // минимальный объём за 24ч в USDT
+	MinOINotional      float64      `json:"min_oi_notional,omitempty"`  // минимальный OI в USDT
+	MinListingAge      int          `json:"min_listing_age,omitempty"`  // дней с листинга
+	ListingAlerts      bool         `json:"listing_alerts,omitempty"`   // уведомлять о листингах и делистингах
+	Stopped            bool         `json:"stopped,omitempty"`          // мониторинг выключен командой /stop
+	PausedUntil        time.Time    `json:"paused_until,omitempty"`     // алерты на паузе до этого момента
+	Referral           string       `json:"referral,omitempty"`         // код из ссылки, по которой пришёл пользователь
+}
+
+// HasUniverseFilters — отсекает ли пользователь неликвидные и свежие тикеры
+func (s UserSettings) HasUniverseFilters() bool {
+	return s.MinQuoteVolume > 0 || s.MinOINotional > 0 || s.MinListingAge > 0
+}
+
+// HasMonitoring — настроена ли у пользователя хотя бы одна метрика
+func (s UserSettings) HasMonitoring() bool {
+	return (s.TimeFrame != "" && (s.ChangeThreshold > 0 || s.VolumeThreshold > 0) && s.TargetBot != "") ||
+		(s.MonitorOI && s.OIThreshold > 0) ||
+		s.FundingThreshold > 0 || s.FundingFlipAlert ||
+		(s.LiqThreshold > 0 && s.LiqWindow > 0) ||
+		(s.VolumeMultiplier > 0 && s.VolumeTimeFrame != "") ||
+		(s.RatioMetric != "" && (s.RatioCross > 0 || s.RatioShift > 0)) ||
+		len(s.Rules) > 0 || s.ListingAlerts
+}
+
+const ModeSpot = "spot"
+
+// EnterSpot переводит пользователя на спот и сбрасывает то, чего на споте нет:
+// OI, фандинг, ликвидации, позиционирование и правила с этими метриками
+func (s *UserSettings) EnterSpot() {
+	s.Mode = ModeSpot
+	s.MonitorOI = false
+	s.FundingThreshold, s.FundingFlipAlert = 0, false
+	s.LiqThreshold, s.LiqWindow = 0, 0
+	s.RatioMetric, s.RatioCross, s.RatioShift, s.RatioWindow = "", 0, 0, 0
+	s.MinOINotional = 0
+	s.Rules = slices.DeleteFunc(s.Rules, func(r rules.Rule) bool {
+		return slices.ContainsFunc(r.Expr.Conditions(), func(c rules.Condition) bool {
+			return c.Metric.FuturesOnly()
+		})
+	})
+}
+
+// Exchanges возвращает рынки, которые мониторит пользователь; по умолчанию фьючерсы Binance
+func (s UserSettings) Exchanges() []string {
+	if s.Mode == ModeSpot {
+		return []string{"binance_spot"}
+	}
+	return s.FuturesExchanges()
+}
+
+// FuturesExchanges возвращает выбранные фьючерсные биржи независимо от режима
+func (s UserSettings) FuturesExchanges() []string {
+	var list []string
+	for _, e := range s.PreferredExchanges {
+		// Спот выбирается режимом, а не списком бирж
+		if e != "binance_spot" {
+			list = append(list, e)
+		}
+	}
+	if len(list) == 0 {
+		return []string{"binance"}
+	}
+	return list
+}
+
+// ToggleExchange добавляет фьючерсную биржу в список или убирает её оттуда
+func (s *UserSettings) ToggleExchange(ex string) {
+	current := s.FuturesExchanges()
+	var next []string
+	found := false
+	for _, e := range current {
+		if e == ex {
+			found = true
+			continue
+		}
+		next = append(next, e)
+	}
+	if !found {
+		next = append(next, ex)
+	}
+	s.PreferredExchanges = next
+}
+
+// Окна по умолчанию, если пользователь не выбрал свои
+var defaultOIWindows = []int{15, 30}
+
+// OILookbacks возвращает окна сравнения OI по возрастанию
+func (s UserSettings) OILookbacks() []time.Duration {
+	windows := s.OIWindows
+	if len(windows) == 0 {
+		windows = defaultOIWindows
+	}
+	res := make([]time.Duration, 0, len(windows))
+	for _, w := range windows {
+		if w > 0 {
+			res = append(res, time.Duration(w)*time.Minute)
+		}
+	}
+	slices.Sort(res)
+	return res
+}
+
+// FormatWindow печатает окно в привычном трейдерам виде: 5m, 1h, 24h
+func FormatWindow(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	}
+	return fmt.Sprintf("%dm", int(d/time.Minute))
+}
+
+// ToggleOIWindow добавляет окно OI в список или убирает его оттуда.
+// Пока пользователь не выбирал окна, переключение начинается с окон по умолчанию.
+func (s *UserSettings) ToggleOIWindow(minutes int) {
+	if len(s.OIWindows) == 0 {
+		s.OIWindows = slices.Clone(defaultOIWindows)
+	}
+	if i := slices.Index(s.OIWindows, minutes); i >= 0 {
+		s.OIWindows = slices.Delete(s.OIWindows, i, i+1)
+		return
+	}
+	s.OIWindows = append(s.OIWindows, minutes)
+}
+
+// NormalizeSymbol приводит ввод пользователя к каноничному тикеру: "btc" и "BTC-USDT" дают "BTCUSDT"
+// Пустая строка — ввод не похож на тикер.
+func NormalizeSymbol(input string) string {
+	sym := exchanges.CanonicalSymbol(input)
+	for _, r := range sym {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return ""
+		}
+	}
+	if sym != "" && !strings.HasSuffix(sym, "USDT") {
+		sym += "USDT"
+	}
+	return sym
+}
+
+// AllowsSymbol — проходит ли каноничный тикер через вотчлист и чёрный список
+func (s UserSettings) AllowsSymbol(instrument string) bool {
+	if slices.Contains(s.Blacklist, instrument) {
+		return false
+	}
+	return len(s.Watchlist) == 0 || slices.Contains(s.Watchlist, instrument)
+}
+
+// Готовые сетапы из нескольких условий
+var rulePresets = map[string]rules.Node{
+	"pump_oi": rules.All(
+		rules.Cond(rules.Condition{Metric: rules.PriceChange, Window: "5m", Op: rules.Greater, Value: 3}),
+		rules.Cond(rules.Condition{Metric: rules.OIChange, Window: "15m", Op: rules.Greater, Value: 5}),
+		rules.Cond(rules.Condition{Metric: rules.VolumeSpike, Window: "5m", Op: rules.GreaterEqual, Value: 3}),
+	),
+	"dump_oi": rules.All(
+		rules.Cond(rules.Condition{Metric: rules.PriceChange, Window: "5m", Op: rules.Less, Value: -3}),
+		rules.Cond(rules.Condition{Metric: rules.OIChange, Window: "15m", Op: rules.Greater, Value: 5}),
+		rules.Cond(rules.Condition{Metric: rules.VolumeSpike, Window: "5m", Op: rules.GreaterEqual, Value: 3}),
+	),
+	"squeeze": rules.All(
+		rules.Cond(rules.Condition{Metric: rules.Funding, Op: rules.Less, Value: -0.05}),
+		rules.Any(
+			rules.Cond(rules.Condition{Metric: rules.OIChange, Window: "1h", Op: rules.Greater, Value: 10}),
+			rules.Cond(rules.Condition{Metric: rules.Liquidations, Window: "15m", Op: rules.Greater, Value: 1000000}),
+		),
+	),
+}
+
+// AddRule сохраняет новое правило и возвращает его
+func (s *UserSettings) AddRule(expr rules.Node) rules.Rule {
+	r := rules.Rule{ID: rules.NextID(s.Rules), Expr: expr}
+	s.Rules = append(s.Rules, r)
+	return r
 }
 
 type OnSettingsChangeFunc func(userID int64, s UserSettings)
@@ -25,6 +220,7 @@ type userSession struct {
 	MessageID int
 	ChatID    int64
 	States    []string
+	Awaiting  string // поле из customInputs, значение которого ждём текстом
 }
 
 type Bot struct {
@@ -32,8 +228,11 @@ type Bot struct {
 	Users        map[int64]*UserSettings
 	Mu           sync.Mutex
 	OnSettingsFn OnSettingsChangeFunc
-	ManagerRef   *BotManager
-	UserSessions map[int64]*userSession
+	StatusFn     MonitorStatusFunc
+	// Секрет для подписи ссылок с настройками; без него ссылки не принимаются
+	DeepLinkSecret []byte
+	ManagerRef     *BotManager
+	UserSessions   map[int64]*userSession
 }
 
 func NewBot(token string) (*Bot, error) {
@@ -49,23 +248,39 @@ func NewBot(token string) (*Bot, error) {
 }
 
 func (b *Bot) HandleUpdate(update tgbotapi.Update) {
-	if update.Message.IsCommand() && update.Message.Command() == "start" {
-		if args := strings.TrimSpace(update.Message.CommandArguments()); args != "" {
-			log.Printf("User %d passed parameters: %s", update.Message.Chat.ID, args)
-			// Парсинг args и настройка пользователя
-		}
-	}
 	if update.Message != nil && update.Message.IsCommand() {
+		chatID := update.Message.Chat.ID
 		switch strings.ToLower(update.Message.Command()) {
 		case "start":
-			b.startCommand(update.Message.Chat.ID, update.Message.From.FirstName)
+			if args := strings.TrimSpace(update.Message.CommandArguments()); args != "" && b.deepLinkStart(chatID, args) {
+				break
+			}
+			b.startCommand(chatID, update.Message.From.FirstName)
 		case "help":
-			b.sendHelp(update.Message.Chat.ID)
+			b.sendHelp(chatID)
+		case "rule":
+			b.ruleCommand(chatID, update.Message.CommandArguments())
+		case "rules":
+			b.rulesCommand(chatID)
+		case "watch":
+			b.symbolListCommand(chatID, update.Message.CommandArguments(), false)
+		case "ignore":
+			b.symbolListCommand(chatID, update.Message.CommandArguments(), true)
+		case "stop":
+			b.stopCommand(chatID)
+		case "pause":
+			b.pauseCommand(chatID, update.Message.CommandArguments())
+		case "resume":
+			b.resumeCommand(chatID)
+		case "status", "settings":
+			b.statusCommand(chatID)
 		default:
-			b.sendUnknown(update.Message.Chat.ID)
+			b.sendUnknown(chatID)
 		}
 	} else if update.Message != nil {
-		b.sendUnknown(update.Message.Chat.ID)
+		if !b.handleTextInput(update.Message) {
+			b.sendUnknown(update.Message.Chat.ID)
+		}
 	} else if update.CallbackQuery != nil {
 		b.handleCallbackQuery(update.CallbackQuery)
 	}
@@ -81,7 +296,10 @@ func (b *Bot) startCommand(chatID int64, firstName string) {
 			tgbotapi.NewInlineKeyboardButtonData("⚠️ Отказ от ответственности", "to:disclaimer"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🚀 Поехали", "to:choose_main_mode"),
+			tgbotapi.NewInlineKeyboardButtonData("🚀 Поехали", "to:choose_exchange"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⚙️ Мои настройки", "my_settings"),
 		),
 	)
 	msg := tgbotapi.NewMessage(chatID, msgText)
@@ -103,8 +321,16 @@ func (b *Bot) startCommand(chatID int64, firstName string) {
 func (b *Bot) sendHelp(chatID int64) {
 	helpText := "📖 *Справка по командам бота:*\n\n" +
 		"/start - Начать работу с ботом\n" +
-		"/help - Показать это сообщение со справкой\n\n" +
-		"Этот бот отслеживает изменения цен и открытого интереса (OI) на фьючерсы криптовалют на Binance.\n" +
+		"/help - Показать это сообщение со справкой\n" +
+		"/rule - Добавить правило, например `/rule price.change(5m) > 3% and oi.change(15m) > 5%`\n" +
+		"/rules - Список правил и удаление\n" +
+		"/watch BTC ETH - Присылать алерты только по этим тикерам\n" +
+		"/ignore XRP - Не присылать алерты по тикеру\n" +
+		"/pause 2h - Приостановить алерты (по умолчанию на час)\n" +
+		"/stop - Выключить мониторинг, /resume - включить снова\n" +
+		"/status - Текущие настройки и состояние мониторинга\n\n" +
+		"Этот бот отслеживает изменения цен и открытого интереса (OI) на фьючерсы криптовалют на Binance, Bybit и OKX, " +
+		"а также цены и объёмы на споте Binance.\n" +
 		"Доступны режимы Scalp, Intraday и Spot."
 	msg := tgbotapi.NewMessage(chatID, helpText)
 	msg.ParseMode = "Markdown"
@@ -124,6 +350,16 @@ func (b *Bot) handleCallbackQuery(callback *tgbotapi.CallbackQuery) {
 	chatID := callback.Message.Chat.ID
 	data := callback.Data
 
+	// Кнопки под ответами на команды работают и без сессии мастера настройки
+	switch {
+	case strings.HasPrefix(data, "delete_rule:"):
+		b.deleteRule(callback)
+		return
+	case strings.HasPrefix(data, "unwatch:"), strings.HasPrefix(data, "unignore:"):
+		b.removeFromSymbolList(callback)
+		return
+	}
+
 	sess, ok := b.UserSessions[chatID]
 	if !ok {
 		b.BotAPI.Request(tgbotapi.NewCallback(callback.ID, ""))
@@ -137,7 +373,15 @@ func (b *Bot) handleCallbackQuery(callback *tgbotapi.CallbackQuery) {
 
 	switch {
 	case data == "back":
+		sess.Awaiting = ""
 		b.popState(chatID)
+		// Переключатели (биржи, окна OI, фильтры) сохраняются при возврате на экран настроек
+		if b.currentState(chatID) == "my_settings" {
+			b.saveSettings(chatID, *b.Users[chatID])
+		}
+		b.renderState(chatID)
+	case data == "my_settings":
+		sess.States = []string{"my_settings"}
 		b.renderState(chatID)
 	case data == "back_to_start":
 		b.UserSessions[chatID].States = []string{"welcome"}
@@ -150,10 +394,27 @@ func (b *Bot) handleCallbackQuery(callback *tgbotapi.CallbackQuery) {
 	case data == "to:choose_main_mode":
 		b.pushState(chatID, "choose_main_mode")
 		b.renderState(chatID)
+	case strings.HasPrefix(data, "toggle_exchange:"):
+		ex := strings.TrimPrefix(data, "toggle_exchange:")
+		if _, exists := b.Users[chatID]; !exists {
+			b.Users[chatID] = &UserSettings{}
+		}
+		b.Users[chatID].ToggleExchange(ex)
+		b.renderState(chatID)
 	case strings.HasPrefix(data, "to:"):
 		newState := strings.TrimPrefix(data, "to:")
 		b.pushState(chatID, newState)
 		b.renderState(chatID)
+	case strings.HasPrefix(data, "custom:"):
+		field := strings.TrimPrefix(data, "custom:")
+		if _, ok := customInputs[field]; !ok {
+			b.editError(chatID, sess)
+			b.BotAPI.Request(tgbotapi.NewCallback(callback.ID, ""))
+			return
+		}
+		sess.Awaiting = field
+		b.pushState(chatID, "custom_input")
+		b.renderState(chatID)
 	case strings.HasPrefix(data, "set_change:"):
 		v := strings.TrimPrefix(data, "set_change:")
 		th, err := strconv.ParseFloat(v, 64)
@@ -165,10 +426,12 @@ func (b *Bot) handleCallbackQuery(callback *tgbotapi.CallbackQuery) {
 		if _, exists := b.Users[chatID]; !exists {
 			b.Users[chatID] = &UserSettings{}
 		}
+		b.Users[chatID].Mode = "scalp"
 		b.Users[chatID].ChangeThreshold = th
 		currentState := b.currentState(chatID)
 		if currentState == "pumps_dumps" {
-			b.pushState(chatID, "choose_timeframe")
+			b.advance(chatID, "choose_timeframe")
+			break
 		}
 		b.renderState(chatID)
 	case strings.HasPrefix(data, "set_time:"):
@@ -177,14 +440,20 @@ func (b *Bot) handleCallbackQuery(callback *tgbotapi.CallbackQuery) {
 			b.Users[chatID] = &UserSettings{}
 		}
 		b.Users[chatID].TimeFrame = tf
-		b.pushState(chatID, "choose_target_bot")
-		b.renderState(chatID)
+		b.advance(chatID, "choose_target_bot")
 	case strings.HasPrefix(data, "target:"):
 		bn := strings.TrimPrefix(data, "target:")
 		if _, exists := b.Users[chatID]; !exists {
 			b.Users[chatID] = &UserSettings{}
 		}
 		b.Users[chatID].TargetBot = bn
+		if b.editingSettings(chatID) {
+			b.advance(chatID, "final")
+			break
+		}
+		// Пройденная заново настройка снимает /stop и /pause
+		b.Users[chatID].Stopped = false
+		b.Users[chatID].PausedUntil = time.Time{}
 		if b.OnSettingsFn != nil {
 			b.OnSettingsFn(chatID, *b.Users[chatID])
 		}
@@ -201,9 +470,21 @@ func (b *Bot) handleCallbackQuery(callback *tgbotapi.CallbackQuery) {
 		if _, exists := b.Users[chatID]; !exists {
 			b.Users[chatID] = &UserSettings{}
 		}
+		b.Users[chatID].Mode = "intraday"
 		b.Users[chatID].MonitorOI = true
 		b.Users[chatID].OIThreshold = oiTh
-		b.pushState(chatID, "choose_target_bot")
+		b.advance(chatID, "intraday_oi_windows")
+	case strings.HasPrefix(data, "toggle_oi_window:"):
+		minutes, err := strconv.Atoi(strings.TrimPrefix(data, "toggle_oi_window:"))
+		if err != nil {
+			b.editError(chatID, sess)
+			b.BotAPI.Request(tgbotapi.NewCallback(callback.ID, ""))
+			return
+		}
+		if _, exists := b.Users[chatID]; !exists {
+			b.Users[chatID] = &UserSettings{}
+		}
+		b.Users[chatID].ToggleOIWindow(minutes)
 		b.renderState(chatID)
 	case strings.HasPrefix(data, "set_pd:"):
 		parts := strings.Split(strings.TrimPrefix(data, "set_pd:"), ":")
@@ -222,10 +503,180 @@ func (b *Bot) handleCallbackQuery(callback *tgbotapi.CallbackQuery) {
 		if _, exists := b.Users[chatID]; !exists {
 			b.Users[chatID] = &UserSettings{}
 		}
+		b.Users[chatID].Mode = "intraday"
 		b.Users[chatID].ChangeThreshold = pdPercent
 		b.Users[chatID].TimeFrame = fmt.Sprintf("%dm", pdMinutes)
-		b.pushState(chatID, "choose_target_bot")
+		b.advance(chatID, "choose_target_bot")
+	case strings.HasPrefix(data, "set_vol_mult:"):
+		v := strings.TrimPrefix(data, "set_vol_mult:")
+		mult, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			b.editError(chatID, sess)
+			b.BotAPI.Request(tgbotapi.NewCallback(callback.ID, ""))
+			return
+		}
+		if _, exists := b.Users[chatID]; !exists {
+			b.Users[chatID] = &UserSettings{}
+		}
+		b.Users[chatID].Mode = "scalp"
+		b.Users[chatID].VolumeMultiplier = mult
+		b.advance(chatID, "volume_timeframe")
+	case strings.HasPrefix(data, "set_vol_time:"):
+		tf := strings.TrimPrefix(data, "set_vol_time:")
+		if _, exists := b.Users[chatID]; !exists {
+			b.Users[chatID] = &UserSettings{}
+		}
+		b.Users[chatID].VolumeTimeFrame = tf
+		b.advance(chatID, "choose_target_bot")
+	case strings.HasPrefix(data, "set_liq:"):
+		parts := strings.Split(strings.TrimPrefix(data, "set_liq:"), ":")
+		if len(parts) != 2 {
+			b.editError(chatID, sess)
+			b.BotAPI.Request(tgbotapi.NewCallback(callback.ID, ""))
+			return
+		}
+		liqUSDT, err1 := strconv.ParseFloat(parts[0], 64)
+		liqMinutes, err2 := strconv.Atoi(parts[1])
+		if err1 != nil || err2 != nil {
+			b.editError(chatID, sess)
+			b.BotAPI.Request(tgbotapi.NewCallback(callback.ID, ""))
+			return
+		}
+		if _, exists := b.Users[chatID]; !exists {
+			b.Users[chatID] = &UserSettings{}
+		}
+		b.Users[chatID].Mode = "scalp"
+		b.Users[chatID].LiqThreshold = liqUSDT
+		b.Users[chatID].LiqWindow = liqMinutes
+		b.advance(chatID, "choose_target_bot")
+	case strings.HasPrefix(data, "set_min_volume:"), strings.HasPrefix(data, "set_min_oi:"), strings.HasPrefix(data, "set_min_age:"):
+		key, v, _ := strings.Cut(data, ":")
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil || n < 0 {
+			b.editError(chatID, sess)
+			b.BotAPI.Request(tgbotapi.NewCallback(callback.ID, ""))
+			return
+		}
+		if _, exists := b.Users[chatID]; !exists {
+			b.Users[chatID] = &UserSettings{}
+		}
+		u := b.Users[chatID]
+		switch key {
+		case "set_min_volume":
+			u.MinQuoteVolume = n
+		case "set_min_oi":
+			u.MinOINotional = n
+		case "set_min_age":
+			u.MinListingAge = int(n)
+		}
+		b.renderState(chatID)
+	case strings.HasPrefix(data, "set_listing:"):
+		if _, exists := b.Users[chatID]; !exists {
+			b.Users[chatID] = &UserSettings{}
+		}
+		b.Users[chatID].ListingAlerts = data == "set_listing:on"
+		b.advance(chatID, "choose_target_bot")
+	case strings.HasPrefix(data, "add_rule_preset:"):
+		expr, ok := rulePresets[strings.TrimPrefix(data, "add_rule_preset:")]
+		if !ok {
+			b.editError(chatID, sess)
+			b.BotAPI.Request(tgbotapi.NewCallback(callback.ID, ""))
+			return
+		}
+		if _, exists := b.Users[chatID]; !exists {
+			b.Users[chatID] = &UserSettings{}
+		}
+		b.Users[chatID].AddRule(expr)
+		b.advance(chatID, "choose_target_bot")
+	case strings.HasPrefix(data, "set_ratio_metric:"):
+		if _, exists := b.Users[chatID]; !exists {
+			b.Users[chatID] = &UserSettings{}
+		}
+		b.Users[chatID].Mode = "intraday"
+		b.Users[chatID].RatioMetric = strings.TrimPrefix(data, "set_ratio_metric:")
+		b.pushState(chatID, "positioning_condition")
 		b.renderState(chatID)
+	case strings.HasPrefix(data, "set_ratio_cross:"):
+		v := strings.TrimPrefix(data, "set_ratio_cross:")
+		level, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			b.editError(chatID, sess)
+			b.BotAPI.Request(tgbotapi.NewCallback(callback.ID, ""))
+			return
+		}
+		if _, exists := b.Users[chatID]; !exists {
+			b.Users[chatID] = &UserSettings{}
+		}
+		b.Users[chatID].RatioCross = level
+		b.advance(chatID, "choose_target_bot")
+	case strings.HasPrefix(data, "set_ratio_shift:"):
+		parts := strings.Split(strings.TrimPrefix(data, "set_ratio_shift:"), ":")
+		if len(parts) != 2 {
+			b.editError(chatID, sess)
+			b.BotAPI.Request(tgbotapi.NewCallback(callback.ID, ""))
+			return
+		}
+		shift, err1 := strconv.ParseFloat(parts[0], 64)
+		window, err2 := strconv.Atoi(parts[1])
+		if err1 != nil || err2 != nil {
+			b.editError(chatID, sess)
+			b.BotAPI.Request(tgbotapi.NewCallback(callback.ID, ""))
+			return
+		}
+		if _, exists := b.Users[chatID]; !exists {
+			b.Users[chatID] = &UserSettings{}
+		}
+		b.Users[chatID].RatioShift = shift
+		b.Users[chatID].RatioWindow = window
+		b.advance(chatID, "choose_target_bot")
+	case strings.HasPrefix(data, "set_funding:"):
+		v := strings.TrimPrefix(data, "set_funding:")
+		th, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			b.editError(chatID, sess)
+			b.BotAPI.Request(tgbotapi.NewCallback(callback.ID, ""))
+			return
+		}
+		if _, exists := b.Users[chatID]; !exists {
+			b.Users[chatID] = &UserSettings{}
+		}
+		b.Users[chatID].Mode = "intraday"
+		b.Users[chatID].FundingThreshold = th
+		b.advance(chatID, "choose_target_bot")
+	case data == "set_funding_flip":
+		if _, exists := b.Users[chatID]; !exists {
+			b.Users[chatID] = &UserSettings{}
+		}
+		b.Users[chatID].Mode = "intraday"
+		b.Users[chatID].FundingFlipAlert = true
+		b.advance(chatID, "choose_target_bot")
+	case strings.HasPrefix(data, "set_spot_change:"), strings.HasPrefix(data, "set_spot_volume:"):
+		isVolume := strings.HasPrefix(data, "set_spot_volume:")
+		v := data[strings.LastIndex(data, ":")+1:]
+		th, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			b.editError(chatID, sess)
+			b.BotAPI.Request(tgbotapi.NewCallback(callback.ID, ""))
+			return
+		}
+		if _, exists := b.Users[chatID]; !exists {
+			b.Users[chatID] = &UserSettings{}
+		}
+		u := b.Users[chatID]
+		u.EnterSpot()
+		if isVolume {
+			u.VolumeThreshold = th
+		} else {
+			u.ChangeThreshold = th
+		}
+		b.advance(chatID, "spot_timeframe")
+	case strings.HasPrefix(data, "set_spot_time:"):
+		tf := strings.TrimPrefix(data, "set_spot_time:")
+		if _, exists := b.Users[chatID]; !exists {
+			b.Users[chatID] = &UserSettings{}
+		}
+		b.Users[chatID].TimeFrame = tf
+		b.advance(chatID, "choose_target_bot")
 	default:
 		log.Printf("Unknown callback data from user %d: %s", chatID, data)
 		b.sendUnknown(chatID)
@@ -269,7 +720,10 @@ func (b *Bot) renderState(chatID int64) {
 				tgbotapi.NewInlineKeyboardButtonData("⚠️ Отказ от ответственности", "to:disclaimer"),
 			),
 			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("🚀 Поехали", "to:choose_main_mode"),
+				tgbotapi.NewInlineKeyboardButtonData("🚀 Поехали", "to:choose_exchange"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("⚙️ Мои настройки", "my_settings"),
 			),
 		)
 	case "description":
@@ -286,6 +740,33 @@ func (b *Bot) renderState(chatID int64) {
 				tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
 			),
 		)
+	case "choose_exchange":
+		if _, exists := b.Users[chatID]; !exists {
+			b.Users[chatID] = &UserSettings{}
+		}
+		s := b.Users[chatID]
+		mark := func(ex, title string) string {
+			for _, e := range s.FuturesExchanges() {
+				if e == ex {
+					return "✅ " + title
+				}
+			}
+			return title
+		}
+		text = "🏦 Выберите биржи для мониторинга (можно несколько):"
+		btn = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(mark("binance", "Binance"), "toggle_exchange:binance"),
+				tgbotapi.NewInlineKeyboardButtonData(mark("bybit", "Bybit"), "toggle_exchange:bybit"),
+				tgbotapi.NewInlineKeyboardButtonData(mark("okx", "OKX"), "toggle_exchange:okx"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("➡️ Далее", "to:choose_main_mode"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
+			),
+		)
 	case "choose_main_mode":
 		text = "📂 Выберите режим:"
 		btn = tgbotapi.NewInlineKeyboardMarkup(
@@ -294,6 +775,92 @@ func (b *Bot) renderState(chatID int64) {
 				tgbotapi.NewInlineKeyboardButtonData("⏱ Intraday", "to:intraday_mode"),
 				tgbotapi.NewInlineKeyboardButtonData("💰 Spot Mode", "to:spot_mode"),
 			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🧩 Сетапы", "to:rule_presets"),
+				tgbotapi.NewInlineKeyboardButtonData("🎯 Тикеры", "to:symbol_lists"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🧹 Фильтр ликвидности", "to:universe_filters"),
+				tgbotapi.NewInlineKeyboardButtonData("🆕 Листинги", "to:listing_alerts"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
+			),
+		)
+	case "symbol_lists":
+		if _, exists := b.Users[chatID]; !exists {
+			b.Users[chatID] = &UserSettings{}
+		}
+		var rows [][]tgbotapi.InlineKeyboardButton
+		text, rows = symbolListsView(*b.Users[chatID])
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
+		))
+		btn = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	case "universe_filters":
+		if _, exists := b.Users[chatID]; !exists {
+			b.Users[chatID] = &UserSettings{}
+		}
+		s := b.Users[chatID]
+		mark := func(current, v float64, title string) string {
+			if current == v {
+				return "✅ " + title
+			}
+			return title
+		}
+		text = "🧹 *Фильтр ликвидности*\nАлерты не придут по тикерам, которые не проходят фильтр. " +
+			"Если биржа не отдаёт нужных данных, тикер не отсекается.\n\n" +
+			"Объём за 24ч / OI в USDT / дней с листинга:"
+		btn = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(mark(s.MinQuoteVolume, 0, "Vol: любой"), "set_min_volume:0"),
+				tgbotapi.NewInlineKeyboardButtonData(mark(s.MinQuoteVolume, 10_000_000, "10M"), "set_min_volume:10000000"),
+				tgbotapi.NewInlineKeyboardButtonData(mark(s.MinQuoteVolume, 50_000_000, "50M"), "set_min_volume:50000000"),
+				tgbotapi.NewInlineKeyboardButtonData(mark(s.MinQuoteVolume, 100_000_000, "100M"), "set_min_volume:100000000"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(mark(s.MinOINotional, 0, "OI: любой"), "set_min_oi:0"),
+				tgbotapi.NewInlineKeyboardButtonData(mark(s.MinOINotional, 5_000_000, "5M"), "set_min_oi:5000000"),
+				tgbotapi.NewInlineKeyboardButtonData(mark(s.MinOINotional, 20_000_000, "20M"), "set_min_oi:20000000"),
+				tgbotapi.NewInlineKeyboardButtonData(mark(s.MinOINotional, 50_000_000, "50M"), "set_min_oi:50000000"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(mark(float64(s.MinListingAge), 0, "Возраст: любой"), "set_min_age:0"),
+				tgbotapi.NewInlineKeyboardButtonData(mark(float64(s.MinListingAge), 7, "7д"), "set_min_age:7"),
+				tgbotapi.NewInlineKeyboardButtonData(mark(float64(s.MinListingAge), 30, "30д"), "set_min_age:30"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("➡️ Далее", "to:choose_target_bot"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
+			),
+		)
+	case "listing_alerts":
+		text = "🆕 Присылать уведомления, когда на выбранных биржах появляется новый контракт " +
+			"и когда контракт уходит на расчёт или поставку перед делистингом?"
+		btn = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("✅ Включить", "set_listing:on"),
+				tgbotapi.NewInlineKeyboardButtonData("❌ Выключить", "set_listing:off"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
+			),
+		)
+	case "rule_presets":
+		text = "🧩 Сетап срабатывает, только когда выполнены все его условия:\n\n" +
+			"🟩 Памп с OI: " + rulePresets["pump_oi"].String() + "\n" +
+			"🟥 Дамп с OI: " + rulePresets["dump_oi"].String() + "\n" +
+			"🩳 Шорт-сквиз: " + rulePresets["squeeze"].String()
+		btn = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🟩 Памп с OI", "add_rule_preset:pump_oi"),
+				tgbotapi.NewInlineKeyboardButtonData("🟥 Дамп с OI", "add_rule_preset:dump_oi"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🩳 Шорт-сквиз", "add_rule_preset:squeeze"),
+			),
 			tgbotapi.NewInlineKeyboardRow(
 				tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
 			),
@@ -303,6 +870,10 @@ func (b *Bot) renderState(chatID int64) {
 		btn = tgbotapi.NewInlineKeyboardMarkup(
 			tgbotapi.NewInlineKeyboardRow(
 				tgbotapi.NewInlineKeyboardButtonData("📈 Pumps/Dumps", "to:pumps_dumps"),
+				tgbotapi.NewInlineKeyboardButtonData("💥 Ликвидации", "to:scalp_liquidations"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🔊 Всплеск объёма", "to:volume_spike"),
 			),
 			tgbotapi.NewInlineKeyboardRow(
 				tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
@@ -317,6 +888,48 @@ func (b *Bot) renderState(chatID int64) {
 				tgbotapi.NewInlineKeyboardButtonData("✅ 3%", "set_change:3"),
 				tgbotapi.NewInlineKeyboardButtonData("✅ 5%", "set_change:5"),
 			),
+			customInputRow("change"),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
+			),
+		)
+	case "scalp_liquidations":
+		text = "💥 Сумма ликвидаций по монете за период:"
+		btn = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("✅ 500K / 5m", "set_liq:500000:5"),
+				tgbotapi.NewInlineKeyboardButtonData("✅ 1M / 5m", "set_liq:1000000:5"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("✅ 1M / 15m", "set_liq:1000000:15"),
+				tgbotapi.NewInlineKeyboardButtonData("✅ 5M / 60m", "set_liq:5000000:60"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
+			),
+		)
+	case "volume_spike":
+		text = "🔊 Во сколько раз объём свечи должен превысить средний за 20 свечей:"
+		btn = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("✅ ×2", "set_vol_mult:2"),
+				tgbotapi.NewInlineKeyboardButtonData("✅ ×3", "set_vol_mult:3"),
+				tgbotapi.NewInlineKeyboardButtonData("✅ ×5", "set_vol_mult:5"),
+				tgbotapi.NewInlineKeyboardButtonData("✅ ×10", "set_vol_mult:10"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
+			),
+		)
+	case "volume_timeframe":
+		text = "⏱ Выберите интервал свечи:"
+		btn = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("⏱ 1m", "set_vol_time:1m"),
+				tgbotapi.NewInlineKeyboardButtonData("⏱ 5m", "set_vol_time:5m"),
+				tgbotapi.NewInlineKeyboardButtonData("⏱ 15m", "set_vol_time:15m"),
+				tgbotapi.NewInlineKeyboardButtonData("⏱ 1h", "set_vol_time:1h"),
+			),
 			tgbotapi.NewInlineKeyboardRow(
 				tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
 			),
@@ -341,6 +954,10 @@ func (b *Bot) renderState(chatID int64) {
 				tgbotapi.NewInlineKeyboardButtonData("📊 Изменение OI", "to:intraday_oi"),
 				tgbotapi.NewInlineKeyboardButtonData("📈 Pumps/Dumps", "to:intraday_pumps_dumps"),
 			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("💸 Funding", "to:intraday_funding"),
+				tgbotapi.NewInlineKeyboardButtonData("⚖️ Позиционирование", "to:positioning"),
+			),
 			tgbotapi.NewInlineKeyboardRow(
 				tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
 			),
@@ -352,6 +969,37 @@ func (b *Bot) renderState(chatID int64) {
 				tgbotapi.NewInlineKeyboardButtonData("✅ 2.5%", "set_oi_threshold:2.5"),
 				tgbotapi.NewInlineKeyboardButtonData("✅ 5%", "set_oi_threshold:5"),
 			),
+			customInputRow("oi_threshold"),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
+			),
+		)
+	case "intraday_oi_windows":
+		if _, exists := b.Users[chatID]; !exists {
+			b.Users[chatID] = &UserSettings{}
+		}
+		s := b.Users[chatID]
+		mark := func(minutes int, title string) string {
+			if slices.Contains(s.OILookbacks(), time.Duration(minutes)*time.Minute) {
+				return "✅ " + title
+			}
+			return title
+		}
+		text = "🕰 С какими моментами сравнивать OI (можно несколько, по умолчанию 15m и 30m):"
+		btn = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(mark(5, "5m"), "toggle_oi_window:5"),
+				tgbotapi.NewInlineKeyboardButtonData(mark(15, "15m"), "toggle_oi_window:15"),
+				tgbotapi.NewInlineKeyboardButtonData(mark(30, "30m"), "toggle_oi_window:30"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(mark(60, "1h"), "toggle_oi_window:60"),
+				tgbotapi.NewInlineKeyboardButtonData(mark(240, "4h"), "toggle_oi_window:240"),
+				tgbotapi.NewInlineKeyboardButtonData(mark(1440, "24h"), "toggle_oi_window:1440"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("➡️ Далее", "to:choose_target_bot"),
+			),
 			tgbotapi.NewInlineKeyboardRow(
 				tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
 			),
@@ -367,6 +1015,109 @@ func (b *Bot) renderState(chatID int64) {
 				tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
 			),
 		)
+	case "spot_mode":
+		text = "💰 Spot Mode выбран! Выберите метрику:"
+		btn = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("📈 Изменение цены", "to:spot_change"),
+				tgbotapi.NewInlineKeyboardButtonData("📊 Объём", "to:spot_volume"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
+			),
+		)
+	case "spot_change":
+		text = "📉 Порог изменения цены на споте (%):"
+		btn = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("✅ 1%", "set_spot_change:1"),
+				tgbotapi.NewInlineKeyboardButtonData("✅ 2%", "set_spot_change:2"),
+				tgbotapi.NewInlineKeyboardButtonData("✅ 3%", "set_spot_change:3"),
+				tgbotapi.NewInlineKeyboardButtonData("✅ 5%", "set_spot_change:5"),
+			),
+			customInputRow("spot_change"),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
+			),
+		)
+	case "spot_volume":
+		text = "📊 Минимальный объём свечи (USDT):"
+		btn = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("✅ 100K", "set_spot_volume:100000"),
+				tgbotapi.NewInlineKeyboardButtonData("✅ 500K", "set_spot_volume:500000"),
+				tgbotapi.NewInlineKeyboardButtonData("✅ 1M", "set_spot_volume:1000000"),
+				tgbotapi.NewInlineKeyboardButtonData("✅ 5M", "set_spot_volume:5000000"),
+			),
+			customInputRow("spot_volume"),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
+			),
+		)
+	case "spot_timeframe":
+		text = "⏱ Выберите интервал свечи:"
+		btn = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("⏱ 1m", "set_spot_time:1m"),
+				tgbotapi.NewInlineKeyboardButtonData("⏱ 5m", "set_spot_time:5m"),
+				tgbotapi.NewInlineKeyboardButtonData("⏱ 15m", "set_spot_time:15m"),
+				tgbotapi.NewInlineKeyboardButtonData("⏱ 1h", "set_spot_time:1h"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
+			),
+		)
+	case "intraday_funding":
+		text = "💸 Алерт по ставке финансирования:\nпорог по модулю ставки или смена её знака"
+		btn = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("✅ 0.05%", "set_funding:0.05"),
+				tgbotapi.NewInlineKeyboardButtonData("✅ 0.1%", "set_funding:0.1"),
+				tgbotapi.NewInlineKeyboardButtonData("✅ 0.2%", "set_funding:0.2"),
+			),
+			customInputRow("funding"),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🔄 Смена знака", "set_funding_flip"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
+			),
+		)
+	case "positioning":
+		text = "⚖️ Выберите соотношение:"
+		btn = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("👥 Long/Short аккаунтов", "set_ratio_metric:long_short"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🔁 Taker Buy/Sell", "set_ratio_metric:taker"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
+			),
+		)
+	case "positioning_condition":
+		text = "⚖️ Когда присылать алерт:"
+		btn = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("✅ Пересечение 1.5", "set_ratio_cross:1.5"),
+				tgbotapi.NewInlineKeyboardButtonData("✅ Пересечение 2", "set_ratio_cross:2"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("✅ Сдвиг 10% / 1h", "set_ratio_shift:10:60"),
+				tgbotapi.NewInlineKeyboardButtonData("✅ Сдвиг 20% / 4h", "set_ratio_shift:20:240"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
+			),
+		)
+	case "custom_input":
+		text, btn = customInputView(sess.Awaiting)
+	case "my_settings":
+		if _, exists := b.Users[chatID]; !exists {
+			b.Users[chatID] = &UserSettings{}
+		}
+		text, btn = b.settingsView(chatID, *b.Users[chatID])
 	case "choose_target_bot":
 		text = "🤖 Выберите бота для уведомлений:"
 		btn = tgbotapi.NewInlineKeyboardMarkup(
@@ -379,7 +1130,7 @@ func (b *Bot) renderState(chatID int64) {
 				tgbotapi.NewInlineKeyboardButtonData("💹 Bot4", "target:bot4"),
 			),
 			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("🔙 Вернуться в начало", "choose_main_mode"),
+				tgbotapi.NewInlineKeyboardButtonData("🔙 Вернуться в начало", "to:choose_main_mode"),
 			),
 		)
 	case "final":
@@ -394,6 +1145,9 @@ func (b *Bot) renderState(chatID int64) {
 			tgbotapi.NewInlineKeyboardRow(
 				tgbotapi.NewInlineKeyboardButtonURL("👉 Перейти в бота", link),
 			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("⚙️ Мои настройки", "my_settings"),
+			),
 			tgbotapi.NewInlineKeyboardRow(
 				tgbotapi.NewInlineKeyboardButtonData("🔙 Вернуться в начало", "back_to_start"),
 			),
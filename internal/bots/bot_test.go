@@ -0,0 +1,37 @@
+package bots
+
+import (
+	"testing"
+
+	"1333/internal/rules"
+)
+
+func TestEnterSpotClearsFuturesSettings(t *testing.T) {
+	var rs []rules.Rule
+	for i, src := range []string{"price.change(5m) > 3%", "price.change(5m) > 3% and funding > 0.1%", "volume(5m) > 1m or oi.change(15m) > 5%"} {
+		expr, err := rules.Parse(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rs = append(rs, rules.Rule{ID: i + 1, Expr: expr})
+	}
+	s := UserSettings{
+		Mode: "intraday", MonitorOI: true, ChangeThreshold: 3,
+		FundingThreshold: 0.1, FundingFlipAlert: true,
+		LiqThreshold: 1e6, LiqWindow: 15,
+		RatioMetric: "long_short", RatioCross: 1, RatioWindow: 60,
+		MinOINotional: 5e6, Rules: rs,
+	}
+	s.EnterSpot()
+
+	if s.Mode != ModeSpot || s.MonitorOI || s.FundingThreshold != 0 || s.FundingFlipAlert ||
+		s.LiqThreshold != 0 || s.RatioMetric != "" || s.MinOINotional != 0 {
+		t.Fatalf("фьючерсные настройки не сброшены: %+v", s)
+	}
+	if s.ChangeThreshold != 3 {
+		t.Fatalf("порог цены сброшен: %v", s.ChangeThreshold)
+	}
+	if len(s.Rules) != 1 || s.Rules[0].ID != 1 {
+		t.Fatalf("остались правила %+v, want только #1", s.Rules)
+	}
+}
@@ -0,0 +1,360 @@
+package bots
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"1333/internal/rules"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Сколько правил может завести один пользователь
+const maxRulesPerUser = 20
+
+const ruleExample = "`/rule price.change(5m) > 3% and oi.change(15m) > 5%`"
+
+func (b *Bot) sendText(chatID int64, text string, markup any) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "Markdown"
+	if markup != nil {
+		msg.ReplyMarkup = markup
+	}
+	if _, err := b.BotAPI.Send(msg); err != nil {
+		log.Printf("Error sending message to %d: %v", chatID, err)
+	}
+}
+
+var markdownEscaper = strings.NewReplacer("_", "\\_", "*", "\\*", "`", "\\`", "[", "\\[")
+
+// escapeMarkdown экранирует текст, который может содержать разметку, например имена метрик
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// ruleCommand разбирает /rule <выражение> и сохраняет правило
+func (b *Bot) ruleCommand(chatID int64, args string) {
+	if strings.TrimSpace(args) == "" {
+		b.sendText(chatID, "🧩 Опишите условие после команды, например:\n"+ruleExample+"\n\n"+
+			"Метрики: `price.change(tf)`, `oi.change(окно)`, `volume(tf)`, `volume.spike(tf)`, "+
+			"`funding`, `liquidations(окно)`, `long_short`, `taker`.\n"+
+			"Условия объединяются через `and` / `or`, группы — скобками.", nil)
+		return
+	}
+
+	expr, err := rules.Parse(args)
+	if err != nil {
+		b.sendText(chatID, fmt.Sprintf("❌ %s\n\nПример: %s", escapeMarkdown(err.Error()), ruleExample), nil)
+		return
+	}
+
+	b.Mu.Lock()
+	s, exists := b.Users[chatID]
+	if !exists {
+		s = &UserSettings{}
+		b.Users[chatID] = s
+	}
+	if len(s.Rules) >= maxRulesPerUser {
+		b.Mu.Unlock()
+		b.sendText(chatID, fmt.Sprintf("❌ Можно завести не больше %d правил. Удалите лишние через /rules.", maxRulesPerUser), nil)
+		return
+	}
+	r := s.AddRule(expr)
+	settings := *s
+	b.Mu.Unlock()
+
+	text := fmt.Sprintf("✅ Правило #%d сохранено:\n`%s`", r.ID, r.Expr)
+	if settings.TargetBot == "" {
+		text += "\n\n⚠️ Бот для алертов ещё не выбран — пройдите настройку через /start."
+	}
+	b.saveSettings(chatID, settings)
+	b.sendText(chatID, text, nil)
+}
+
+// rulesCommand показывает правила пользователя с кнопками удаления
+func (b *Bot) rulesCommand(chatID int64) {
+	b.Mu.Lock()
+	var list []rules.Rule
+	if s, ok := b.Users[chatID]; ok {
+		list = append(list, s.Rules...)
+	}
+	b.Mu.Unlock()
+
+	if len(list) == 0 {
+		b.sendText(chatID, "📋 Правил пока нет. Добавьте первое:\n"+ruleExample, nil)
+		return
+	}
+
+	text := "📋 *Ваши правила:*\n"
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, r := range list {
+		text += fmt.Sprintf("\n#%d `%s`", r.ID, r.Expr)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("🗑 Удалить #%d", r.ID), fmt.Sprintf("delete_rule:%d", r.ID)),
+		))
+	}
+	b.sendText(chatID, text, tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// deleteRule обрабатывает кнопку удаления под списком /rules
+func (b *Bot) deleteRule(callback *tgbotapi.CallbackQuery) {
+	chatID := callback.Message.Chat.ID
+	id, err := strconv.Atoi(strings.TrimPrefix(callback.Data, "delete_rule:"))
+	if err != nil {
+		b.BotAPI.Request(tgbotapi.NewCallback(callback.ID, ""))
+		return
+	}
+
+	b.Mu.Lock()
+	s, ok := b.Users[chatID]
+	removed := false
+	if ok {
+		for i, r := range s.Rules {
+			if r.ID == id {
+				s.Rules = append(s.Rules[:i:i], s.Rules[i+1:]...)
+				removed = true
+				break
+			}
+		}
+	}
+	var settings UserSettings
+	if removed {
+		settings = *s
+	}
+	b.Mu.Unlock()
+
+	if !removed {
+		b.BotAPI.Request(tgbotapi.NewCallback(callback.ID, "Правило уже удалено"))
+		return
+	}
+	b.saveSettings(chatID, settings)
+	b.BotAPI.Request(tgbotapi.NewCallback(callback.ID, fmt.Sprintf("Правило #%d удалено", id)))
+	b.rulesCommand(chatID)
+}
+
+// Сколько тикеров можно держать в вотчлисте или чёрном списке
+const maxSymbolsPerList = 100
+
+// symbolListsView рисует вотчлист и чёрный список с кнопками удаления
+func symbolListsView(s UserSettings) (string, [][]tgbotapi.InlineKeyboardButton) {
+	text := "🎯 *Фильтр тикеров*\n\n"
+	if len(s.Watchlist) == 0 {
+		text += "👀 Вотчлист пуст — алерты идут по всем тикерам.\n"
+	} else {
+		text += "👀 Алерты только по: " + strings.Join(s.Watchlist, ", ") + "\n"
+	}
+	if len(s.Blacklist) == 0 {
+		text += "🚫 Игнор-лист пуст.\n"
+	} else {
+		text += "🚫 Игнорируются: " + strings.Join(s.Blacklist, ", ") + "\n"
+	}
+	text += "\nДобавить: `/watch BTC ETH`, `/ignore XRP`. Нажмите на тикер, чтобы убрать его из списка."
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	addButtons := func(list []string, prefix, icon string) {
+		var row []tgbotapi.InlineKeyboardButton
+		for _, sym := range list {
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(icon+" "+sym, prefix+sym))
+			if len(row) == 3 {
+				rows = append(rows, row)
+				row = nil
+			}
+		}
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	addButtons(s.Watchlist, "unwatch:", "👀")
+	addButtons(s.Blacklist, "unignore:", "🚫")
+	return text, rows
+}
+
+// symbolListCommand обрабатывает /watch и /ignore: с аргументами добавляет тикеры,
+// без аргументов показывает списки
+func (b *Bot) symbolListCommand(chatID int64, args string, ignore bool) {
+	b.Mu.Lock()
+	s, exists := b.Users[chatID]
+	if !exists {
+		s = &UserSettings{}
+		b.Users[chatID] = s
+	}
+	list := &s.Watchlist
+	other := &s.Blacklist
+	if ignore {
+		list, other = other, list
+	}
+
+	changed := false
+	for _, arg := range strings.FieldsFunc(args, func(r rune) bool { return r == ' ' || r == ',' }) {
+		sym := NormalizeSymbol(arg)
+		if sym == "" || slices.Contains(*list, sym) {
+			continue
+		}
+		if len(*list) >= maxSymbolsPerList {
+			break
+		}
+		// Тикер не может быть одновременно в вотчлисте и в игноре
+		if i := slices.Index(*other, sym); i >= 0 {
+			*other = slices.Delete(*other, i, i+1)
+		}
+		*list = append(*list, sym)
+		changed = true
+	}
+	settings := *s
+	b.Mu.Unlock()
+
+	if changed {
+		b.saveSettings(chatID, settings)
+	}
+	text, rows := symbolListsView(settings)
+	var markup any
+	if len(rows) > 0 {
+		markup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	}
+	b.sendText(chatID, text, markup)
+}
+
+// removeFromSymbolList обрабатывает кнопки unwatch:/unignore: и в ответе на команду,
+// и на экране мастера настройки
+func (b *Bot) removeFromSymbolList(callback *tgbotapi.CallbackQuery) {
+	chatID := callback.Message.Chat.ID
+	prefix, sym, _ := strings.Cut(callback.Data, ":")
+
+	b.Mu.Lock()
+	s, exists := b.Users[chatID]
+	if !exists {
+		s = &UserSettings{}
+		b.Users[chatID] = s
+	}
+	list := &s.Watchlist
+	if prefix == "unignore" {
+		list = &s.Blacklist
+	}
+	if i := slices.Index(*list, sym); i >= 0 {
+		*list = slices.Delete(*list, i, i+1)
+	}
+	settings := *s
+
+	sess, inWizard := b.UserSessions[chatID]
+	inWizard = inWizard && sess.MessageID == callback.Message.MessageID
+	if inWizard {
+		b.renderState(chatID)
+	}
+	b.Mu.Unlock()
+
+	b.saveSettings(chatID, settings)
+	if !inWizard {
+		text, rows := symbolListsView(settings)
+		edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, callback.Message.MessageID, text, tgbotapi.NewInlineKeyboardMarkup(rows...))
+		edit.ParseMode = "Markdown"
+		if _, err := b.BotAPI.Send(edit); err != nil {
+			log.Printf("Error editing symbol lists for user %d: %v", chatID, err)
+		}
+	}
+	b.BotAPI.Request(tgbotapi.NewCallback(callback.ID, sym+" убран из списка"))
+}
+
+// saveSettings сохраняет изменения, сделанные командой. Пока бот для алертов не выбран,
+// мониторинг не запускается: настройки сохранятся по завершении мастера.
+func (b *Bot) saveSettings(chatID int64, s UserSettings) {
+	if s.TargetBot != "" && b.OnSettingsFn != nil {
+		b.OnSettingsFn(chatID, s)
+	}
+}
+
+// Пауза по умолчанию и самая долгая пауза для /pause
+const (
+	defaultPause = time.Hour
+	maxPause     = 7 * 24 * time.Hour
+)
+
+// noMonitoringText объясняет, почему /stop, /pause и /resume ничего не сделали
+func noMonitoringText(action string) string {
+	return "🤷 " + action + " нечего: мониторинг ещё не настроен. Пройдите настройку через /start."
+}
+
+// updateMonitoring меняет настройки работающего мониторинга. ok=false, если
+// мониторинга у пользователя нет и менять нечего. Без бота для алертов saveSettings
+// ничего не сохраняет, поэтому такие настройки тоже считаются ненастроенными.
+func (b *Bot) updateMonitoring(chatID int64, fn func(s *UserSettings)) (settings UserSettings, ok bool) {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+	s, exists := b.Users[chatID]
+	if !exists || s.TargetBot == "" || !s.HasMonitoring() {
+		return UserSettings{}, false
+	}
+	fn(s)
+	return *s, true
+}
+
+// stopCommand выключает мониторинг до /resume или повторной настройки
+func (b *Bot) stopCommand(chatID int64) {
+	settings, ok := b.updateMonitoring(chatID, func(s *UserSettings) {
+		s.Stopped = true
+		s.PausedUntil = time.Time{}
+	})
+	if !ok {
+		b.sendText(chatID, noMonitoringText("Останавливать"), nil)
+		return
+	}
+	b.saveSettings(chatID, settings)
+	b.sendText(chatID, "⏹ Мониторинг остановлен, алерты больше не придут.\nНастройки сохранены — /resume включит их снова.", nil)
+}
+
+// pauseCommand разбирает /pause [длительность] и приостанавливает алерты
+func (b *Bot) pauseCommand(chatID int64, args string) {
+	d := defaultPause
+	if arg := strings.ToLower(strings.TrimSpace(args)); arg != "" {
+		var err error
+		if d, err = rules.ParseWindow(arg); err != nil || d <= 0 {
+			b.sendText(chatID, "❌ Не понял длительность. Примеры: `/pause 30m`, `/pause 4h`, `/pause 1d`.", nil)
+			return
+		}
+	}
+	if d > maxPause {
+		b.sendText(chatID, "❌ Пауза может длиться не больше 7 дней. Чтобы выключить алерты насовсем, используйте /stop.", nil)
+		return
+	}
+
+	until := time.Now().Add(d).Truncate(time.Minute)
+	stopped := false
+	settings, ok := b.updateMonitoring(chatID, func(s *UserSettings) {
+		if stopped = s.Stopped; !stopped {
+			s.PausedUntil = until
+		}
+	})
+	switch {
+	case !ok:
+		b.sendText(chatID, noMonitoringText("Ставить на паузу"), nil)
+		return
+	case stopped:
+		b.sendText(chatID, "⏹ Мониторинг уже остановлен. Включить его снова — /resume.", nil)
+		return
+	}
+	b.saveSettings(chatID, settings)
+	b.sendText(chatID, fmt.Sprintf("⏸ Алерты на паузе до %s UTC. Возобновить раньше — /resume.", until.UTC().Format("02.01 15:04")), nil)
+}
+
+// resumeCommand снимает /stop и /pause
+func (b *Bot) resumeCommand(chatID int64) {
+	wasActive := false
+	settings, ok := b.updateMonitoring(chatID, func(s *UserSettings) {
+		wasActive = !s.Stopped && !time.Now().Before(s.PausedUntil)
+		s.Stopped = false
+		s.PausedUntil = time.Time{}
+	})
+	switch {
+	case !ok:
+		b.sendText(chatID, noMonitoringText("Возобновлять"), nil)
+		return
+	case wasActive:
+		b.sendText(chatID, "▶️ Мониторинг и так работает.", nil)
+		return
+	}
+	b.saveSettings(chatID, settings)
+	b.sendText(chatID, "▶️ Мониторинг возобновлён.", nil)
+}
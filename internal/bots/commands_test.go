@@ -0,0 +1,35 @@
+package bots
+
+import "testing"
+
+func TestUpdateMonitoringRequiresConfiguredMonitoring(t *testing.T) {
+	tests := []struct {
+		name   string
+		user   *UserSettings
+		wantOK bool
+	}{
+		{"нет пользователя", nil, false},
+		{"мастер не пройден", &UserSettings{ChangeThreshold: 3, TimeFrame: "5m"}, false},
+		{"бот выбран, метрик нет", &UserSettings{TargetBot: "bot1"}, false},
+		{"настроен", &UserSettings{ChangeThreshold: 3, TimeFrame: "5m", TargetBot: "bot1"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Bot{Users: map[int64]*UserSettings{}}
+			if tt.user != nil {
+				b.Users[1] = tt.user
+			}
+			called := false
+			_, ok := b.updateMonitoring(1, func(s *UserSettings) {
+				called = true
+				s.Stopped = true
+			})
+			if ok != tt.wantOK || called != tt.wantOK {
+				t.Fatalf("ok=%v called=%v, want %v", ok, called, tt.wantOK)
+			}
+			if !tt.wantOK && tt.user != nil && tt.user.Stopped {
+				t.Fatal("настройки без мониторинга не должны меняться")
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package bots
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Ссылка вида t.me/<бот>?start=<payload>. Telegram пропускает в payload не больше
+// 64 символов из A-Z, a-z, 0-9, _ и -, поэтому формат такой:
+//
+//	mi-co-t2p5-f15m-bbot2-rchan1_<подпись>
+//
+// Поля разделены дефисом, первая буква поля — ключ, остальное — значение:
+// m — режим (s scalp, i intraday, p spot), c — метрика (p цена, o OI, v объём на споте,
+// f funding), t — порог с p вместо десятичной точки, f — таймфрейм, b — бот для алертов,
+// r — реферальный код. После подчёркивания — первые байты HMAC-SHA256 в hex.
+const (
+	maxDeepLinkLength = 64
+	deepLinkSigBytes  = 6
+)
+
+var (
+	deepLinkModes = map[string]string{"s": "scalp", "i": "intraday", "p": ModeSpot}
+	// Таймфреймы, которые предлагает мастер
+	deepLinkTimeFrames = []string{"1m", "3m", "5m", "15m", "30m", "1h"}
+)
+
+// DeepLink — настройки, зашитые в ссылку на бота
+type DeepLink struct {
+	Mode      string // scalp, intraday или spot
+	Metric    string // p, o, v или f
+	Threshold float64
+	TimeFrame string
+	TargetBot string
+	Referral  string
+}
+
+var errBadSignature = errors.New("неверная подпись")
+
+// ParseDeepLink проверяет подпись payload и разбирает его
+func ParseDeepLink(payload string, secret []byte) (DeepLink, error) {
+	if len(secret) == 0 {
+		return DeepLink{}, errors.New("секрет для ссылок не задан")
+	}
+	if len(payload) > maxDeepLinkLength {
+		return DeepLink{}, errors.New("слишком длинная ссылка")
+	}
+	body, sig, ok := strings.Cut(payload, "_")
+	if !ok {
+		return DeepLink{}, errors.New("нет подписи")
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, signDeepLink(body, secret)) {
+		return DeepLink{}, errBadSignature
+	}
+
+	var l DeepLink
+	for _, field := range strings.Split(body, "-") {
+		if field == "" {
+			return DeepLink{}, errors.New("пустое поле")
+		}
+		key, v := field[:1], field[1:]
+		if !isAlnum(v) {
+			return DeepLink{}, fmt.Errorf("недопустимые символы в поле %s", key)
+		}
+		switch key {
+		case "m":
+			mode, ok := deepLinkModes[v]
+			if !ok {
+				return DeepLink{}, fmt.Errorf("неизвестный режим %q", v)
+			}
+			l.Mode = mode
+		case "c":
+			l.Metric = v
+		case "t":
+			th, err := strconv.ParseFloat(strings.ReplaceAll(v, "p", "."), 64)
+			if err != nil || math.IsNaN(th) || math.IsInf(th, 0) {
+				return DeepLink{}, fmt.Errorf("не удалось разобрать порог %q", v)
+			}
+			l.Threshold = th
+		case "f":
+			if !slices.Contains(deepLinkTimeFrames, v) {
+				return DeepLink{}, fmt.Errorf("неподдерживаемый таймфрейм %q", v)
+			}
+			l.TimeFrame = v
+		case "b":
+			l.TargetBot = v
+		case "r":
+			l.Referral = v
+		default:
+			return DeepLink{}, fmt.Errorf("неизвестное поле %q", key)
+		}
+	}
+	if _, err := l.input(); err != nil {
+		return DeepLink{}, err
+	}
+	return l, nil
+}
+
+// Encode собирает подписанный payload для ссылки t.me/<бот>?start=...
+func (l DeepLink) Encode(secret []byte) (string, error) {
+	if len(secret) == 0 {
+		return "", errors.New("секрет для ссылок не задан")
+	}
+	var fields []string
+	for code, mode := range deepLinkModes {
+		if mode == l.Mode {
+			fields = append(fields, "m"+code)
+		}
+	}
+	fields = append(fields, "c"+l.Metric, "t"+strings.ReplaceAll(strconv.FormatFloat(l.Threshold, 'f', -1, 64), ".", "p"))
+	for key, v := range map[string]string{"f": l.TimeFrame, "b": l.TargetBot, "r": l.Referral} {
+		if v != "" {
+			fields = append(fields, key+v)
+		}
+	}
+	slices.Sort(fields)
+
+	body := strings.Join(fields, "-")
+	payload := body + "_" + hex.EncodeToString(signDeepLink(body, secret))
+	if len(payload) > maxDeepLinkLength {
+		return "", fmt.Errorf("ссылка длиннее %d символов", maxDeepLinkLength)
+	}
+	if _, err := ParseDeepLink(payload, secret); err != nil {
+		return "", err
+	}
+	return payload, nil
+}
+
+// input подбирает поле ручного ввода, чьи пределы и логика применяются к порогу из ссылки
+func (l DeepLink) input() (customInput, error) {
+	var field string
+	switch {
+	case l.Metric == "p" && l.Mode == ModeSpot:
+		field = "spot_change"
+	case l.Metric == "p":
+		field = "change"
+	case l.Metric == "v" && l.Mode == ModeSpot:
+		field = "spot_volume"
+	case l.Metric == "o" && l.Mode != ModeSpot:
+		field = "oi_threshold"
+	case l.Metric == "f" && l.Mode != ModeSpot:
+		field = "funding"
+	default:
+		return customInput{}, fmt.Errorf("метрика %q недоступна в режиме %q", l.Metric, l.Mode)
+	}
+	in := customInputs[field]
+	if !(l.Threshold >= in.min && l.Threshold <= in.max) {
+		return customInput{}, fmt.Errorf("порог %v вне диапазона %v–%v", l.Threshold, in.min, in.max)
+	}
+	return in, nil
+}
+
+// Apply переносит настройки из ссылки в настройки пользователя
+func (l DeepLink) Apply(s *UserSettings) {
+	in, err := l.input()
+	if err != nil {
+		return
+	}
+	in.apply(s, l.Threshold)
+	if l.Mode != "" && l.Mode != ModeSpot {
+		s.Mode = l.Mode
+	}
+	if l.TimeFrame != "" {
+		s.TimeFrame = l.TimeFrame
+	}
+	if l.TargetBot != "" {
+		s.TargetBot = l.TargetBot
+	}
+	// Засчитываем только первого пригласившего
+	if s.Referral == "" {
+		s.Referral = l.Referral
+	}
+}
+
+func signDeepLink(body string, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(body))
+	return mac.Sum(nil)[:deepLinkSigBytes]
+}
+
+func isAlnum(s string) bool {
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// deepLinkStart настраивает пользователя по ссылке из /start и открывает мастер
+// на первом недостающем шаге. Возвращает false, если ссылку применить нельзя.
+func (b *Bot) deepLinkStart(chatID int64, payload string) bool {
+	l, err := ParseDeepLink(payload, b.DeepLinkSecret)
+	if err != nil {
+		log.Printf("User %d: неверная ссылка %q: %v", chatID, payload, err)
+		return false
+	}
+
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	if l.TargetBot != "" {
+		if _, ok := b.ManagerRef.Bots[l.TargetBot]; !ok || l.TargetBot == "main" {
+			log.Printf("User %d: бот %s из ссылки не найден", chatID, l.TargetBot)
+			l.TargetBot = ""
+		}
+	}
+	if _, exists := b.Users[chatID]; !exists {
+		b.Users[chatID] = &UserSettings{}
+	}
+	s := b.Users[chatID]
+	l.Apply(s)
+	log.Printf("User %d: настройки из ссылки применены %+v", chatID, l)
+
+	states := []string{"welcome"}
+	// Таймфрейм для метрик по свечам ссылка может не задать
+	switch {
+	case (l.Metric == "p" || l.Metric == "v") && s.TimeFrame == "" && s.Mode == ModeSpot:
+		states = append(states, "spot_timeframe")
+	case l.Metric == "p" && s.TimeFrame == "":
+		states = append(states, "choose_timeframe")
+	case s.TargetBot == "":
+		states = append(states, "choose_target_bot")
+	default:
+		s.Stopped = false
+		s.PausedUntil = time.Time{}
+		if b.OnSettingsFn != nil {
+			b.OnSettingsFn(chatID, *s)
+		}
+		states = append(states, "final")
+	}
+
+	sentMsg, err := b.BotAPI.Send(tgbotapi.NewMessage(chatID, "🔗 Настройки из ссылки применены"))
+	if err != nil {
+		log.Printf("User %d: ошибка отправки стартового сообщения: %v", chatID, err)
+		return true
+	}
+	b.UserSessions[chatID] = &userSession{
+		ChatID:    chatID,
+		MessageID: sentMsg.MessageID,
+		States:    states,
+	}
+	b.renderState(chatID)
+	return true
+}
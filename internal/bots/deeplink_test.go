@@ -0,0 +1,91 @@
+package bots
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+var testSecret = []byte("secret")
+
+// signed подписывает тело так же, как Encode, чтобы проверять разбор произвольных полей
+func signed(body string) string {
+	return body + "_" + hex.EncodeToString(signDeepLink(body, testSecret))
+}
+
+func TestDeepLinkRoundTrip(t *testing.T) {
+	want := DeepLink{Mode: "intraday", Metric: "o", Threshold: 2.5, TimeFrame: "15m", TargetBot: "bot2", Referral: "chan1"}
+	payload, err := want.Encode(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payload) > maxDeepLinkLength {
+		t.Fatalf("payload длиной %d не влезает в /start", len(payload))
+	}
+	got, err := ParseDeepLink(payload, testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseDeepLinkErrors(t *testing.T) {
+	valid := signed("mi-co-t5")
+	sig := valid[strings.Index(valid, "_")+1:]
+
+	tests := []struct {
+		name    string
+		payload string
+		secret  []byte
+		wantErr string
+	}{
+		{"без секрета", valid, nil, "секрет"},
+		{"чужой секрет", valid, []byte("other"), "подпись"},
+		{"подпись изменена", "mi-co-t5_" + strings.Repeat("0", len(sig)), testSecret, "подпись"},
+		{"подпись обрезана", valid[:len(valid)-2], testSecret, "подпись"},
+		{"пустая подпись", "mi-co-t5_", testSecret, "подпись"},
+		{"подпись не hex", "mi-co-t5_zzzzzzzzzzzz", testSecret, "подпись"},
+		{"тело изменено", "mi-co-t9_" + sig, testSecret, "подпись"},
+		{"нет подписи", "mi-co-t5", testSecret, "нет подписи"},
+		{"слишком длинная", signed("mi-co-t5-r" + strings.Repeat("a", 60)), testSecret, "длинная"},
+		{"неизвестное поле", signed("mi-co-t5-x1"), testSecret, "неизвестное поле"},
+		{"пустое поле", signed("mi--co-t5"), testSecret, "пустое поле"},
+		{"неизвестный режим", signed("mz-co-t5"), testSecret, "режим"},
+		{"неподдерживаемый таймфрейм", signed("ms-cp-t3-f3h"), testSecret, "таймфрейм"},
+		{"NaN", signed("mi-co-tNaN"), testSecret, "порог"},
+		{"бесконечность", signed("mi-co-tinf"), testSecret, "порог"},
+		{"порог ниже диапазона", signed("mi-co-t0p01"), testSecret, "диапазона"},
+		{"порог выше диапазона", signed("mi-co-t1000"), testSecret, "диапазона"},
+		{"без порога", signed("mi-co"), testSecret, "диапазона"},
+		{"OI на споте", signed("mp-co-t5"), testSecret, "недоступна"},
+		{"неизвестная метрика", signed("mi-cz-t5"), testSecret, "недоступна"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDeepLink(tt.payload, tt.secret)
+			if err == nil {
+				t.Fatalf("ParseDeepLink(%q) не вернул ошибку", tt.payload)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ошибка %q, ожидалось упоминание %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDeepLinkApply(t *testing.T) {
+	l, err := ParseDeepLink(signed("ms-cp-t2p5-f5m-bbot1-rref"), testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := UserSettings{Referral: "first"}
+	l.Apply(&s)
+	if s.Mode != "scalp" || s.ChangeThreshold != 2.5 || s.TimeFrame != "5m" || s.TargetBot != "bot1" {
+		t.Fatalf("настройки применены неверно: %+v", s)
+	}
+	if s.Referral != "first" {
+		t.Fatalf("реферальный код перезаписан: %q", s.Referral)
+	}
+}
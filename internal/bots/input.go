@@ -0,0 +1,153 @@
+package bots
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// customInput — порог, который можно ввести текстом вместо кнопки
+type customInput struct {
+	prompt   string
+	example  string
+	min, max float64
+	apply    func(s *UserSettings, v float64)
+	next     string // следующий шаг мастера, как у соответствующей кнопки
+}
+
+var customInputs = map[string]customInput{
+	"change": {
+		prompt: "порог изменения цены в %", example: "3,5",
+		min: 0.1, max: 100,
+		apply: func(s *UserSettings, v float64) {
+			s.Mode = "scalp"
+			s.ChangeThreshold = v
+		},
+		next: "choose_timeframe",
+	},
+	"oi_threshold": {
+		prompt: "порог изменения OI в %", example: "4",
+		min: 0.1, max: 100,
+		apply: func(s *UserSettings, v float64) {
+			s.Mode = "intraday"
+			s.MonitorOI = true
+			s.OIThreshold = v
+		},
+		next: "intraday_oi_windows",
+	},
+	"spot_change": {
+		prompt: "порог изменения цены на споте в %", example: "1,5",
+		min: 0.1, max: 100,
+		apply: func(s *UserSettings, v float64) {
+			s.EnterSpot()
+			s.ChangeThreshold = v
+		},
+		next: "spot_timeframe",
+	},
+	"spot_volume": {
+		prompt: "минимальный объём свечи в USDT", example: "250000",
+		min: 1000, max: 1e10,
+		apply: func(s *UserSettings, v float64) {
+			s.EnterSpot()
+			s.VolumeThreshold = v
+		},
+		next: "spot_timeframe",
+	},
+	"funding": {
+		prompt: "порог ставки финансирования в %", example: "0,075",
+		min: 0.01, max: 5,
+		apply: func(s *UserSettings, v float64) {
+			s.Mode = "intraday"
+			s.FundingThreshold = v
+		},
+		next: "choose_target_bot",
+	},
+}
+
+// customInputRow — кнопка «Своё значение» под кнопками с готовыми порогами
+func customInputRow(field string) []tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("✏️ Своё значение", "custom:"+field),
+	)
+}
+
+// customInputView рисует приглашение ввести значение текстом
+func customInputView(field string) (string, tgbotapi.InlineKeyboardMarkup) {
+	in := customInputs[field]
+	text := fmt.Sprintf("✏️ Отправьте %s сообщением — число от %s до %s, например `%s`.",
+		in.prompt, formatNumber(in.min), formatNumber(in.max), in.example)
+	btn := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
+		),
+	)
+	return text, btn
+}
+
+// parseNumber разбирает введённое пользователем число: запятая — десятичный
+// разделитель, пробелы между разрядами и знак % допускаются
+func parseNumber(s string) (float64, error) {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "%"))
+	s = strings.ReplaceAll(strings.ReplaceAll(s, " ", ""), ",", ".")
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("не число")
+	}
+	return v, nil
+}
+
+// parse разбирает введённый порог и проверяет, что он в допустимых пределах
+func (in customInput) parse(text string) (float64, error) {
+	v, err := parseNumber(text)
+	if err != nil {
+		return 0, err
+	}
+	if v < in.min || v > in.max {
+		return 0, fmt.Errorf("вне диапазона %v–%v", in.min, in.max)
+	}
+	return v, nil
+}
+
+// handleTextInput принимает значение, которого ждёт мастер после «Своё значение».
+// Возвращает false, если бот ничего не ждёт от пользователя.
+func (b *Bot) handleTextInput(msg *tgbotapi.Message) bool {
+	chatID := msg.Chat.ID
+
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	sess, ok := b.UserSessions[chatID]
+	if !ok || sess.Awaiting == "" || b.currentState(chatID) != "custom_input" {
+		return false
+	}
+	in := customInputs[sess.Awaiting]
+
+	v, err := in.parse(msg.Text)
+	if err != nil {
+		b.sendText(chatID, fmt.Sprintf("❌ Нужно число от %s до %s, например `%s`. Попробуйте ещё раз или нажмите «Назад».",
+			formatNumber(in.min), formatNumber(in.max), in.example), nil)
+		return true
+	}
+
+	if _, exists := b.Users[chatID]; !exists {
+		b.Users[chatID] = &UserSettings{}
+	}
+	in.apply(b.Users[chatID], v)
+	sess.Awaiting = ""
+	b.popState(chatID)
+	log.Printf("User %d entered custom value %v", chatID, v)
+
+	// Продолжаем мастер новым сообщением под ответом пользователя, чтобы не искать его выше
+	sentMsg, err := b.BotAPI.Send(tgbotapi.NewMessage(chatID, "✅"))
+	if err != nil {
+		log.Printf("Error sending message to %d: %v", chatID, err)
+		return true
+	}
+	sess.MessageID = sentMsg.MessageID
+	b.advance(chatID, in.next)
+	return true
+}
@@ -0,0 +1,82 @@
+package bots
+
+import "testing"
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		src     string
+		want    float64
+		wantErr bool
+	}{
+		{"3", 3, false},
+		{"3,5", 3.5, false},
+		{"0.075", 0.075, false},
+		{" 2,5% ", 2.5, false},
+		{"5 %", 5, false},
+		{"250 000", 250000, false},
+		{"1 000 000,5", 1000000.5, false},
+		{"-1", -1, false},
+		{"", 0, true},
+		{"abc", 0, true},
+		{"3,5,1", 0, true},
+		{"NaN", 0, true},
+		{"inf", 0, true},
+		{"-Inf", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			got, err := parseNumber(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseNumber(%q) err = %v, wantErr %v", tt.src, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("parseNumber(%q) = %v, want %v", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomInputLimits(t *testing.T) {
+	tests := []struct {
+		field   string
+		src     string
+		wantErr bool
+	}{
+		{"change", "0,1", false},
+		{"change", "100", false},
+		{"change", "0,05", true},
+		{"change", "100,5", true},
+		{"change", "-3", true},
+		{"oi_threshold", "4", false},
+		{"oi_threshold", "150", true},
+		{"spot_change", "1,5", false},
+		{"spot_change", "0", true},
+		{"spot_volume", "250 000", false},
+		{"spot_volume", "999", true},
+		{"spot_volume", "20 000 000 000", true},
+		{"funding", "0,075", false},
+		{"funding", "0,005", true},
+		{"funding", "6%", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field+" "+tt.src, func(t *testing.T) {
+			in, ok := customInputs[tt.field]
+			if !ok {
+				t.Fatalf("нет поля %s", tt.field)
+			}
+			_, err := in.parse(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parse(%q) err = %v, wantErr %v", tt.src, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Пример в приглашении сам должен проходить проверку
+func TestCustomInputExamples(t *testing.T) {
+	for field, in := range customInputs {
+		if _, err := in.parse(in.example); err != nil {
+			t.Errorf("%s: пример %q не принимается: %v", field, in.example, err)
+		}
+	}
+}
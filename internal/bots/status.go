@@ -0,0 +1,202 @@
+package bots
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// MonitorStatus — что известно о работающем мониторе пользователя
+type MonitorStatus struct {
+	Running     bool
+	LastAlert   time.Time
+	AlertsToday int // с начала суток по UTC
+}
+
+type MonitorStatusFunc func(userID int64) MonitorStatus
+
+// statusCommand открывает экран «Мои настройки» отдельным сообщением
+func (b *Bot) statusCommand(chatID int64) {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+
+	if _, exists := b.Users[chatID]; !exists {
+		b.Users[chatID] = &UserSettings{}
+	}
+	msg := tgbotapi.NewMessage(chatID, "⚙️")
+	sentMsg, err := b.BotAPI.Send(msg)
+	if err != nil {
+		log.Printf("Error sending status message to %d: %v", chatID, err)
+		return
+	}
+	b.UserSessions[chatID] = &userSession{
+		ChatID:    chatID,
+		MessageID: sentMsg.MessageID,
+		States:    []string{"my_settings"},
+	}
+	b.renderState(chatID)
+}
+
+// editingSettings — правит ли пользователь поля с экрана «Мои настройки», а не идёт по мастеру
+func (b *Bot) editingSettings(chatID int64) bool {
+	sess, ok := b.UserSessions[chatID]
+	return ok && len(sess.States) > 0 && sess.States[0] == "my_settings"
+}
+
+// advance переходит к следующему шагу мастера. Если пользователь правит поле с экрана
+// «Мои настройки», изменения сохраняются сразу и он возвращается на этот экран.
+// Вызывается под Mu.
+func (b *Bot) advance(chatID int64, next string) {
+	if b.editingSettings(chatID) {
+		b.UserSessions[chatID].States = []string{"my_settings"}
+		b.saveSettings(chatID, *b.Users[chatID])
+	} else {
+		b.pushState(chatID, next)
+	}
+	b.renderState(chatID)
+}
+
+// settingsView рисует экран «Мои настройки» с кнопками правки полей
+func (b *Bot) settingsView(chatID int64, s UserSettings) (string, tgbotapi.InlineKeyboardMarkup) {
+	var st MonitorStatus
+	if b.StatusFn != nil {
+		st = b.StatusFn(chatID)
+	}
+	now := time.Now()
+
+	text := "⚙️ *Мои настройки*\n\n"
+	switch {
+	case s.TargetBot == "" || !s.HasMonitoring():
+		text += "⚪️ Мониторинг не настроен — пройдите /start\n"
+	case s.Stopped:
+		text += "⏹ Мониторинг остановлен — /resume\n"
+	case now.Before(s.PausedUntil):
+		text += fmt.Sprintf("⏸ На паузе до %s UTC — /resume\n", s.PausedUntil.UTC().Format("02.01 15:04"))
+	case st.Running:
+		text += "🟢 Мониторинг работает\n"
+	default:
+		text += "🟡 Мониторинг запускается\n"
+	}
+	if !st.LastAlert.IsZero() {
+		text += fmt.Sprintf("🔔 Последний алерт: %s UTC, сегодня: %d\n", st.LastAlert.UTC().Format("02.01 15:04"), st.AlertsToday)
+	} else {
+		text += "🔔 Алертов пока не было\n"
+	}
+
+	text += "\n"
+	if s.Mode != "" {
+		text += "📂 Режим: " + s.Mode + "\n"
+	}
+	text += "🏦 Биржи: " + escapeMarkdown(strings.Join(s.Exchanges(), ", ")) + "\n"
+	if s.ChangeThreshold > 0 {
+		text += fmt.Sprintf("📈 Изменение цены: %s%%", formatNumber(s.ChangeThreshold))
+		if s.TimeFrame != "" {
+			text += " за " + s.TimeFrame
+		}
+		text += "\n"
+	}
+	if s.VolumeThreshold > 0 {
+		text += fmt.Sprintf("📊 Объём свечи: от %s USDT\n", formatNumber(s.VolumeThreshold))
+	}
+	if s.MonitorOI && s.OIThreshold > 0 {
+		var windows []string
+		for _, w := range s.OILookbacks() {
+			windows = append(windows, FormatWindow(w))
+		}
+		text += fmt.Sprintf("📊 OI: %s%%, окна %s\n", formatNumber(s.OIThreshold), strings.Join(windows, ", "))
+	}
+	if s.VolumeMultiplier > 0 {
+		text += fmt.Sprintf("🔊 Всплеск объёма: ×%s на %s\n", formatNumber(s.VolumeMultiplier), s.VolumeTimeFrame)
+	}
+	if s.FundingThreshold > 0 {
+		text += fmt.Sprintf("💸 Funding: от %s%%\n", formatNumber(s.FundingThreshold))
+	}
+	if s.FundingFlipAlert {
+		text += "💸 Funding: смена знака\n"
+	}
+	if s.LiqThreshold > 0 {
+		text += fmt.Sprintf("💥 Ликвидации: от %s USDT за %dm\n", formatNumber(s.LiqThreshold), s.LiqWindow)
+	}
+	if s.RatioMetric != "" {
+		text += "⚖️ Позиционирование: " + escapeMarkdown(s.RatioMetric) + "\n"
+	}
+	if len(s.Rules) > 0 {
+		text += fmt.Sprintf("🧩 Правил: %d — /rules\n", len(s.Rules))
+	}
+	if len(s.Watchlist) > 0 {
+		text += "👀 Вотчлист: " + strings.Join(s.Watchlist, ", ") + "\n"
+	}
+	if len(s.Blacklist) > 0 {
+		text += "🚫 Игнор: " + strings.Join(s.Blacklist, ", ") + "\n"
+	}
+	if s.HasUniverseFilters() {
+		text += fmt.Sprintf("🧹 Фильтр: объём от %s, OI от %s, от %d дн. с листинга\n",
+			formatNumber(s.MinQuoteVolume), formatNumber(s.MinOINotional), s.MinListingAge)
+	}
+	if s.ListingAlerts {
+		text += "🆕 Уведомления о листингах\n"
+	}
+	if s.TargetBot != "" {
+		name := s.TargetBot
+		if username := b.ManagerRef.Usernames[s.TargetBot]; username != "" {
+			name = "@" + username
+		}
+		text += "🤖 Бот для алертов: " + escapeMarkdown(name) + "\n"
+	}
+
+	changeState, timeState := "pumps_dumps", "choose_timeframe"
+	if s.Mode == ModeSpot {
+		changeState, timeState = "spot_change", "spot_timeframe"
+	}
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📈 Порог цены", "to:"+changeState),
+			tgbotapi.NewInlineKeyboardButtonData("⏱ Таймфрейм", "to:"+timeState),
+		),
+	}
+	// На споте одна площадка и нет OI, выбирать там нечего
+	if s.Mode == ModeSpot {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📊 Объём", "to:spot_volume"),
+			tgbotapi.NewInlineKeyboardButtonData("🤖 Бот", "to:choose_target_bot"),
+		))
+	} else {
+		rows = append(rows,
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("📊 Порог OI", "to:intraday_oi"),
+				tgbotapi.NewInlineKeyboardButtonData("🕰 Окна OI", "to:intraday_oi_windows"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🏦 Биржи", "to:choose_exchange"),
+				tgbotapi.NewInlineKeyboardButtonData("🤖 Бот", "to:choose_target_bot"),
+			),
+		)
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🎯 Тикеры", "to:symbol_lists"),
+			tgbotapi.NewInlineKeyboardButtonData("🧹 Фильтр ликвидности", "to:universe_filters"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔄 Обновить", "my_settings"),
+		),
+	)
+	return text, tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// formatNumber печатает число коротко: 2.5, 500K, 10M
+func formatNumber(v float64) string {
+	suffix := ""
+	switch {
+	case v >= 1e6:
+		v, suffix = v/1e6, "M"
+	case v >= 1e3:
+		v, suffix = v/1e3, "K"
+	}
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64) + suffix
+}
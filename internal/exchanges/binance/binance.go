@@ -3,32 +3,68 @@ package binance
 import (
 	"context"
 	"fmt"
-	"log"
+	"sort"
 	"strconv"
-	"sync"
 	"time"
 
+	"1333/internal/exchanges"
+
 	"github.com/adshao/go-binance/v2/futures"
 )
 
 func NewClient(apiKey, apiSecret string) *futures.Client {
 	client := futures.NewClient(apiKey, apiSecret)
+	client.HTTPClient = newLimitedHTTPClient(FuturesLimiter)
 	return client
 }
 
 func GetUSDMFuturesSymbols(client *futures.Client, ctx context.Context) ([]string, error) {
+	instruments, err := GetUSDMFuturesInstruments(client, ctx)
+	if err != nil {
+		return nil, err
+	}
+	var symbols []string
+	for _, in := range instruments {
+		if in.Status == exchanges.StatusTrading {
+			symbols = append(symbols, in.Symbol)
+		}
+	}
+	return symbols, nil
+}
+
+// GetUSDMFuturesInstruments возвращает USDT-перпетуалы во всех статусах с датой листинга
+func GetUSDMFuturesInstruments(client *futures.Client, ctx context.Context) ([]exchanges.Instrument, error) {
 	exchangeInfo, err := client.NewExchangeInfoService().Do(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get exchange info: %w", err)
 	}
 
-	var symbols []string
+	var instruments []exchanges.Instrument
 	for _, s := range exchangeInfo.Symbols {
-		if s.ContractType == "PERPETUAL" && s.QuoteAsset == "USDT" && s.Status == "TRADING" {
-			symbols = append(symbols, s.Symbol)
+		if s.ContractType == "PERPETUAL" && s.QuoteAsset == "USDT" {
+			in := exchanges.Instrument{Symbol: s.Symbol, Status: contractStatus(s.Status)}
+			if s.OnboardDate > 0 {
+				in.ListedAt = time.UnixMilli(s.OnboardDate)
+			}
+			instruments = append(instruments, in)
 		}
 	}
-	return symbols, nil
+	return instruments, nil
+}
+
+// contractStatus переводит статус контракта Binance в общий
+func contractStatus(status string) string {
+	switch status {
+	case "TRADING":
+		return exchanges.StatusTrading
+	case "PENDING_TRADING":
+		return exchanges.StatusPending
+	case "PRE_SETTLE", "SETTLING":
+		return exchanges.StatusSettling
+	case "PRE_DELIVERING", "DELIVERING", "DELIVERED":
+		return exchanges.StatusDelivering
+	}
+	return exchanges.StatusClosed
 }
 
 func GetChangePercent(client *futures.Client, ctx context.Context, symbol, timeframe string) (prevClose float64, currClose float64, err error) {
@@ -57,12 +93,71 @@ func GetChangePercent(client *futures.Client, ctx context.Context, symbol, timef
 	return prevClose, currClose, nil
 }
 
-func GetOpenInterest(client *futures.Client, symbol string) (float64, error) {
-	startTime := time.Now()
-	defer func() {
-		log.Printf("[DEBUG] GetOpenInterest for %s took %s", symbol, time.Since(startTime))
-	}()
-	oi, err := client.NewGetOpenInterestService().Symbol(symbol).Do(context.Background())
+func GetKlines(client *futures.Client, ctx context.Context, symbol, timeframe string, limit int) ([]exchanges.Kline, error) {
+	klines, err := client.NewKlinesService().
+		Symbol(symbol).
+		Interval(timeframe).
+		Limit(limit).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get klines: %w", err)
+	}
+	return convertKlines(symbol, klines)
+}
+
+// GetKlinesSince возвращает свечи, открытые начиная с since; используется для догрузки пропусков стрима
+func GetKlinesSince(client *futures.Client, ctx context.Context, symbol, timeframe string, since time.Time) ([]exchanges.Kline, error) {
+	klines, err := client.NewKlinesService().
+		Symbol(symbol).
+		Interval(timeframe).
+		StartTime(since.UnixMilli()).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get klines: %w", err)
+	}
+	return convertKlines(symbol, klines)
+}
+
+func convertKlines(symbol string, klines []*futures.Kline) ([]exchanges.Kline, error) {
+	result := make([]exchanges.Kline, 0, len(klines))
+	for _, k := range klines {
+		kl, err := convertKline(k)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse kline for %s: %w", symbol, err)
+		}
+		result = append(result, kl)
+	}
+	return result, nil
+}
+
+func convertKline(k *futures.Kline) (exchanges.Kline, error) {
+	var kl exchanges.Kline
+	var err error
+	kl.OpenTime = time.UnixMilli(k.OpenTime)
+	kl.CloseTime = time.UnixMilli(k.CloseTime)
+	if kl.Open, err = strconv.ParseFloat(k.Open, 64); err != nil {
+		return kl, err
+	}
+	if kl.High, err = strconv.ParseFloat(k.High, 64); err != nil {
+		return kl, err
+	}
+	if kl.Low, err = strconv.ParseFloat(k.Low, 64); err != nil {
+		return kl, err
+	}
+	if kl.Close, err = strconv.ParseFloat(k.Close, 64); err != nil {
+		return kl, err
+	}
+	if kl.Volume, err = strconv.ParseFloat(k.Volume, 64); err != nil {
+		return kl, err
+	}
+	if kl.QuoteVolume, err = strconv.ParseFloat(k.QuoteAssetVolume, 64); err != nil {
+		return kl, err
+	}
+	return kl, nil
+}
+
+func GetOpenInterest(client *futures.Client, ctx context.Context, symbol string) (float64, error) {
+	oi, err := client.NewGetOpenInterestService().Symbol(symbol).Do(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get open interest for %s: %w", symbol, err)
 	}
@@ -75,37 +170,59 @@ func GetOpenInterest(client *futures.Client, symbol string) (float64, error) {
 	return oiValue, nil
 }
 
-func GetCurrentPrice(client *futures.Client, symbol string) (float64, error) {
+// GetOpenInterestHistory возвращает историю OI по символу с шагом period, от старых точек к новым.
+// Binance хранит её только за последние 30 дней.
+func GetOpenInterestHistory(client *futures.Client, ctx context.Context, symbol, period string, limit int) ([]exchanges.OIPoint, error) {
+	stats, err := client.NewOpenInterestStatisticsService().
+		Symbol(symbol).
+		Period(period).
+		Limit(limit).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open interest history for %s: %w", symbol, err)
+	}
+
+	points := make([]exchanges.OIPoint, 0, len(stats))
+	for _, st := range stats {
+		oi, err := strconv.ParseFloat(st.SumOpenInterest, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse open interest history for %s: %w", symbol, err)
+		}
+		points = append(points, exchanges.OIPoint{Time: time.UnixMilli(st.Timestamp), OI: oi})
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Time.Before(points[j].Time) })
+	return points, nil
+}
+
+func GetCurrentPrice(client *futures.Client, ctx context.Context, symbol string) (float64, error) {
 	prices, err := client.NewListPricesService().
 		Symbol(symbol).
-		Do(context.Background())
+		Do(ctx)
 	if err != nil || len(prices) == 0 {
 		return 0, fmt.Errorf("failed to get price for %s: %w", symbol, err)
 	}
 	return strconv.ParseFloat(prices[0].Price, 64)
 }
 
-func InitializeOI(client *futures.Client, symbols []string) map[string]float64 {
-	results := make(map[string]float64)
-	mu := sync.Mutex{}
-	wg := sync.WaitGroup{}
-
-	for _, symbol := range symbols {
-		wg.Add(1)
-		go func(sym string) {
-			defer wg.Done()
-			oi, err := GetOpenInterest(client, sym)
-			if err != nil {
-				log.Printf("[ERROR] Failed to get OI for %s: %v", sym, err)
-				return
-			}
-			mu.Lock()
-			results[sym] = oi
-			mu.Unlock()
+func GetFundingRate(client *futures.Client, ctx context.Context, symbol string) (exchanges.Funding, error) {
+	res, err := client.NewPremiumIndexService().Symbol(symbol).Do(ctx)
+	if err != nil || len(res) == 0 {
+		return exchanges.Funding{}, fmt.Errorf("failed to get premium index for %s: %w", symbol, err)
+	}
 
-		}(symbol)
+	rate, err := strconv.ParseFloat(res[0].LastFundingRate, 64)
+	if err != nil {
+		return exchanges.Funding{}, fmt.Errorf("failed to parse funding rate for %s: %w", symbol, err)
+	}
+	markPrice, err := strconv.ParseFloat(res[0].MarkPrice, 64)
+	if err != nil {
+		return exchanges.Funding{}, fmt.Errorf("failed to parse mark price for %s: %w", symbol, err)
 	}
 
-	wg.Wait()
-	return results
+	return exchanges.Funding{
+		Symbol:          symbol,
+		Rate:            rate,
+		MarkPrice:       markPrice,
+		NextFundingTime: time.UnixMilli(res[0].NextFundingTime),
+	}, nil
 }
@@ -0,0 +1,105 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"1333/internal/exchanges"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// Сколько запросов OI выполняется параллельно
+const oiWorkers = 10
+
+// GetAll24hTickers возвращает суточную статистику по всем контрактам одним запросом
+func GetAll24hTickers(client *futures.Client, ctx context.Context) (map[string]exchanges.Ticker, error) {
+	stats, err := client.NewListPriceChangeStatsService().Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get 24h tickers: %w", err)
+	}
+
+	tickers := make(map[string]exchanges.Ticker, len(stats))
+	for _, st := range stats {
+		lastPrice, err1 := strconv.ParseFloat(st.LastPrice, 64)
+		changePercent, err2 := strconv.ParseFloat(st.PriceChangePercent, 64)
+		quoteVolume, err3 := strconv.ParseFloat(st.QuoteVolume, 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			log.Printf("[ERROR] Failed to parse 24h ticker for %s", st.Symbol)
+			continue
+		}
+		tickers[st.Symbol] = exchanges.Ticker{
+			Symbol:             st.Symbol,
+			LastPrice:          lastPrice,
+			PriceChangePercent: changePercent,
+			QuoteVolume:        quoteVolume,
+		}
+	}
+	return tickers, nil
+}
+
+// GetAllMarkPrices возвращает mark price и ставку финансирования всех контрактов одним запросом
+func GetAllMarkPrices(client *futures.Client, ctx context.Context) (map[string]exchanges.Funding, error) {
+	res, err := client.NewPremiumIndexService().Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get premium index: %w", err)
+	}
+
+	result := make(map[string]exchanges.Funding, len(res))
+	for _, p := range res {
+		rate, err1 := strconv.ParseFloat(p.LastFundingRate, 64)
+		markPrice, err2 := strconv.ParseFloat(p.MarkPrice, 64)
+		if err1 != nil || err2 != nil {
+			log.Printf("[ERROR] Failed to parse premium index for %s", p.Symbol)
+			continue
+		}
+		result[p.Symbol] = exchanges.Funding{
+			Symbol:          p.Symbol,
+			Rate:            rate,
+			MarkPrice:       markPrice,
+			NextFundingTime: time.UnixMilli(p.NextFundingTime),
+		}
+	}
+	return result, nil
+}
+
+// GetOpenInterests запрашивает OI по символам пулом из oiWorkers воркеров.
+// Символы, по которым запрос не удался, в результат не попадают.
+func GetOpenInterests(client *futures.Client, ctx context.Context, symbols []string) map[string]float64 {
+	results := make(map[string]float64, len(symbols))
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+
+	jobs := make(chan string)
+	for i := 0; i < oiWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for sym := range jobs {
+				oi, err := GetOpenInterest(client, ctx, sym)
+				if err != nil {
+					log.Printf("[ERROR] Failed to get OI for %s: %v", sym, err)
+					continue
+				}
+				mu.Lock()
+				results[sym] = oi
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, symbol := range symbols {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- symbol
+	}
+	close(jobs)
+
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,211 @@
+package binance
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Оставляем запас бюджета под запросы, которые мы не учитываем (другие процессы на том же IP)
+	limiterHeadroom = 0.9
+
+	minBanBackoff = time.Second
+	maxBanBackoff = 5 * time.Minute
+)
+
+// Limiter распределяет бюджет веса запросов Binance в пределах окна (обычно минутного),
+// сверяется с заголовком X-MBX-USED-WEIGHT-1M и выдерживает паузу после 429/418.
+type Limiter struct {
+	name   string
+	limit  int
+	period time.Duration
+	// Заголовок с расходом по данным сервера, пустой — сервер расход не сообщает
+	usedHeader string
+
+	mu           sync.Mutex
+	window       time.Time // начало текущего окна
+	used         int
+	blockedUntil time.Time
+	backoff      time.Duration
+}
+
+// Общие лимитеры на IP: у фьючерсов и спота раздельные бюджеты
+var (
+	FuturesLimiter = NewLimiter("Futures", 2400)
+	SpotLimiter    = NewLimiter("Spot", 6000)
+	// Статистика /futures/data лимитируется отдельно: 1000 запросов за 5 минут на IP,
+	// вес в общий бюджет не входит и в заголовках не сообщается
+	FuturesStatsLimiter = &Limiter{name: "Futures stats", limit: 1000, period: 5 * time.Minute}
+)
+
+const futuresStatsPrefix = "/futures/data/"
+
+func NewLimiter(name string, weightPerMinute int) *Limiter {
+	return &Limiter{name: name, limit: weightPerMinute, period: time.Minute, usedHeader: "X-MBX-USED-WEIGHT-1M"}
+}
+
+// Wait резервирует weight единиц веса, при необходимости дожидаясь следующего окна
+// или окончания бана. Возвращает ошибку только при отмене ctx.
+func (l *Limiter) Wait(ctx context.Context, weight int) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.rollWindow(now)
+
+		var wait time.Duration
+		switch {
+		case now.Before(l.blockedUntil):
+			wait = l.blockedUntil.Sub(now)
+		case float64(l.used+weight) > float64(l.limit)*limiterHeadroom:
+			wait = l.window.Add(l.period).Sub(now)
+		default:
+			l.used += weight
+			l.mu.Unlock()
+			return nil
+		}
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Usage возвращает израсходованный в текущем окне вес и лимит
+func (l *Limiter) Usage() (used, limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rollWindow(time.Now())
+	if time.Now().Before(l.blockedUntil) {
+		return l.limit, l.limit
+	}
+	return l.used, l.limit
+}
+
+// UsageRatio — доля израсходованного бюджета, 1 во время бана
+func (l *Limiter) UsageRatio() float64 {
+	used, limit := l.Usage()
+	return float64(used) / float64(limit)
+}
+
+func (l *Limiter) rollWindow(now time.Time) {
+	if w := now.Truncate(l.period); w.After(l.window) {
+		l.window = w
+		l.used = 0
+	}
+}
+
+func (l *Limiter) observe(res *http.Response) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rollWindow(time.Now())
+
+	// Сервер знает точный расход, включая чужие запросы с нашего IP
+	if l.usedHeader != "" {
+		if used, err := strconv.Atoi(res.Header.Get(l.usedHeader)); err == nil && used > l.used {
+			l.used = used
+		}
+	}
+
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusTeapot:
+		pause := time.Duration(0)
+		if sec, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && sec > 0 {
+			pause = time.Duration(sec) * time.Second
+		} else {
+			l.backoff = min(max(l.backoff*2, minBanBackoff), maxBanBackoff)
+			pause = l.backoff
+		}
+		l.blockedUntil = time.Now().Add(pause)
+		log.Printf("[Binance %s] Превышен лимит запросов (HTTP %d), пауза %s", l.name, res.StatusCode, pause)
+	default:
+		l.backoff = 0
+	}
+}
+
+// limitedTransport пропускает каждый HTTP-запрос клиента через Limiter,
+// запросы статистики /futures/data — через FuturesStatsLimiter
+type limitedTransport struct {
+	base    http.RoundTripper
+	limiter *Limiter
+}
+
+func newLimitedHTTPClient(limiter *Limiter) *http.Client {
+	return &http.Client{Transport: &limitedTransport{base: http.DefaultTransport, limiter: limiter}}
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	limiter, weight := t.limiter, 1
+	if strings.HasPrefix(req.URL.Path, futuresStatsPrefix) {
+		limiter = FuturesStatsLimiter
+	} else {
+		weight = requestWeight(req.URL.Path, req.URL.Query())
+	}
+	if err := limiter.Wait(req.Context(), weight); err != nil {
+		return nil, err
+	}
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	limiter.observe(res)
+	return res, nil
+}
+
+// requestWeight — вес эндпоинта по документации Binance
+func requestWeight(path string, q url.Values) int {
+	withSymbol := q.Get("symbol") != ""
+	switch {
+	case path == "/fapi/v1/klines":
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		switch {
+		case limit > 0 && limit < 100:
+			return 1
+		case limit > 0 && limit < 500:
+			return 2
+		case limit > 1000:
+			return 10
+		default:
+			return 5
+		}
+	case path == "/fapi/v1/ticker/24hr":
+		if withSymbol {
+			return 1
+		}
+		return 40
+	case path == "/fapi/v1/ticker/price":
+		if withSymbol {
+			return 1
+		}
+		return 2
+	case path == "/fapi/v1/premiumIndex":
+		if withSymbol {
+			return 1
+		}
+		return 10
+	case path == "/api/v3/exchangeInfo":
+		return 20
+	case path == "/api/v3/ticker/24hr":
+		if withSymbol {
+			return 2
+		}
+		return 80
+	case path == "/api/v3/ticker/price":
+		if withSymbol {
+			return 2
+		}
+		return 4
+	case strings.HasPrefix(path, "/api/v3/"):
+		return 2
+	default:
+		return 1
+	}
+}
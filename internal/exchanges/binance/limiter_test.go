@@ -0,0 +1,143 @@
+package binance
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func response(status int, header map[string]string) *http.Response {
+	res := &http.Response{StatusCode: status, Header: make(http.Header)}
+	for k, v := range header {
+		res.Header.Set(k, v)
+	}
+	return res
+}
+
+func TestLimiterObserveUsedWeight(t *testing.T) {
+	tests := []struct {
+		name   string
+		local  int
+		header string
+		want   int
+	}{
+		{"сервер насчитал больше", 10, "500", 500},
+		{"сервер насчитал меньше", 10, "5", 10},
+		{"нет заголовка", 10, "", 10},
+		{"мусор в заголовке", 10, "abc", 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLimiter("test", 1000)
+			if err := l.Wait(context.Background(), tt.local); err != nil {
+				t.Fatal(err)
+			}
+			h := map[string]string{}
+			if tt.header != "" {
+				h["X-MBX-USED-WEIGHT-1M"] = tt.header
+			}
+			l.observe(response(http.StatusOK, h))
+			if used, _ := l.Usage(); used != tt.want {
+				t.Fatalf("used = %d, want %d", used, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimiterRateLimited(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		repeat     int
+		want       time.Duration
+	}{
+		{"429 с Retry-After", http.StatusTooManyRequests, "7", 1, 7 * time.Second},
+		{"418 с Retry-After", http.StatusTeapot, "120", 1, 120 * time.Second},
+		{"429 без Retry-After", http.StatusTooManyRequests, "", 1, minBanBackoff},
+		{"повторные 429 удваивают паузу", http.StatusTooManyRequests, "", 3, 4 * minBanBackoff},
+		{"пауза не больше максимальной", http.StatusTooManyRequests, "", 20, maxBanBackoff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLimiter("test", 1000)
+			h := map[string]string{}
+			if tt.retryAfter != "" {
+				h["Retry-After"] = tt.retryAfter
+			}
+			for i := 0; i < tt.repeat; i++ {
+				l.observe(response(tt.status, h))
+			}
+			pause := time.Until(l.blockedUntil)
+			if pause > tt.want || pause < tt.want-time.Second {
+				t.Fatalf("пауза %s, want %s", pause, tt.want)
+			}
+			if ratio := l.UsageRatio(); ratio != 1 {
+				t.Fatalf("во время бана UsageRatio = %v, want 1", ratio)
+			}
+
+			// Запрос во время бана ждёт его окончания
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			if err := l.Wait(ctx, 1); err == nil {
+				t.Fatal("Wait не дождался окончания бана")
+			}
+		})
+	}
+}
+
+func TestLimiterBackoffResetsAfterSuccess(t *testing.T) {
+	l := NewLimiter("test", 1000)
+	l.observe(response(http.StatusTooManyRequests, nil))
+	l.observe(response(http.StatusTooManyRequests, nil))
+	l.observe(response(http.StatusOK, nil))
+	l.observe(response(http.StatusTooManyRequests, nil))
+	if l.backoff != minBanBackoff {
+		t.Fatalf("backoff = %s, want %s", l.backoff, minBanBackoff)
+	}
+}
+
+func TestLimiterWaitHeadroom(t *testing.T) {
+	l := NewLimiter("test", 100)
+	if err := l.Wait(context.Background(), 90); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, 1); err == nil {
+		t.Fatal("Wait вышел за запас бюджета")
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestLimitedTransportStatsBudget(t *testing.T) {
+	l := NewLimiter("test", 2400)
+	tr := &limitedTransport{
+		base: roundTripFunc(func(*http.Request) (*http.Response, error) {
+			return response(http.StatusOK, map[string]string{"X-MBX-USED-WEIGHT-1M": "0"}), nil
+		}),
+		limiter: l,
+	}
+	statsBefore, _ := FuturesStatsLimiter.Usage()
+
+	for _, path := range []string{"/fapi/v1/klines?symbol=BTCUSDT&limit=2", "/futures/data/globalLongShortAccountRatio?symbol=BTCUSDT"} {
+		u, _ := url.Parse("https://fapi.binance.com" + path)
+		if _, err := tr.RoundTrip(&http.Request{Method: http.MethodGet, URL: u}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if used, _ := l.Usage(); used != 1 {
+		t.Fatalf("общий вес %d, want 1: статистика не должна его расходовать", used)
+	}
+	if stats, _ := FuturesStatsLimiter.Usage(); stats != statsBefore+1 {
+		t.Fatalf("бюджет статистики %d, want %d", stats, statsBefore+1)
+	}
+}
@@ -0,0 +1,82 @@
+package binance
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"1333/internal/exchanges"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// Самое длинное окно, за которое можно запросить сумму ликвидаций
+const maxLiquidationWindow = time.Hour
+
+type liquidation struct {
+	time     time.Time
+	long     bool // ликвидирована длинная позиция (ордер на продажу)
+	notional float64
+}
+
+// LiquidationTracker слушает стрим !forceOrder@arr и хранит ликвидации
+// за последний maxLiquidationWindow, чтобы считать суммы в скользящих окнах.
+type LiquidationTracker struct {
+	mu     sync.Mutex
+	events map[string][]liquidation
+}
+
+func NewLiquidationTracker() *LiquidationTracker {
+	return &LiquidationTracker{events: make(map[string][]liquidation)}
+}
+
+// Run читает стрим ликвидаций до отмены ctx, переподключаясь при обрывах
+func (t *LiquidationTracker) Run(ctx context.Context) {
+	onEvent := func(e *futures.WsLiquidationOrderEvent) {
+		o := e.LiquidationOrder
+		qty, err1 := strconv.ParseFloat(o.AccumulatedFilledQty, 64)
+		price, err2 := strconv.ParseFloat(o.AvgPrice, 64)
+		if err1 != nil || err2 != nil {
+			log.Printf("[WS forceOrder] Ошибка разбора ликвидации %s", o.Symbol)
+			return
+		}
+		t.Add(o.Symbol, time.UnixMilli(o.TradeTime), o.Side == futures.SideTypeSell, qty*price)
+	}
+
+	serveWithReconnect(ctx, "forceOrder", func(errHandler futures.ErrHandler) (chan struct{}, chan struct{}, error) {
+		return futures.WsAllLiquidationOrderServe(onEvent, errHandler)
+	}, nil)
+}
+
+func (t *LiquidationTracker) Add(symbol string, at time.Time, long bool, notional float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	events := append(t.events[symbol], liquidation{time: at, long: long, notional: notional})
+	cutoff := time.Now().Add(-maxLiquidationWindow)
+	i := 0
+	for i < len(events) && events[i].time.Before(cutoff) {
+		i++
+	}
+	t.events[symbol] = events[i:]
+}
+
+// Sum возвращает номинал ликвидаций по символу за последние window
+func (t *LiquidationTracker) Sum(symbol string, window time.Duration) exchanges.LiquidationStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var stats exchanges.LiquidationStats
+	cutoff := time.Now().Add(-window)
+	events := t.events[symbol]
+	for i := len(events) - 1; i >= 0 && !events[i].time.Before(cutoff); i-- {
+		if events[i].long {
+			stats.Long += events[i].notional
+		} else {
+			stats.Short += events[i].notional
+		}
+	}
+	return stats
+}
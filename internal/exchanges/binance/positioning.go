@@ -0,0 +1,65 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"1333/internal/exchanges"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// GetPositioning возвращает историю соотношения лонг/шорт аккаунтов и объёма
+// покупок/продаж тейкеров по символу, от старых точек к новым
+func GetPositioning(client *futures.Client, ctx context.Context, symbol, period string, limit int) ([]exchanges.Positioning, error) {
+	ls, err := client.NewLongShortRatioService().
+		Symbol(symbol).
+		Period(period).
+		Limit(limit).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get long/short ratio for %s: %w", symbol, err)
+	}
+	taker, err := client.NewTakerLongShortRatioService().
+		Symbol(symbol).
+		Period(period).
+		Limit(uint32(limit)).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get taker buy/sell ratio for %s: %w", symbol, err)
+	}
+
+	byTime := make(map[int64]*exchanges.Positioning, len(ls))
+	point := func(ts int64) *exchanges.Positioning {
+		p, ok := byTime[ts]
+		if !ok {
+			p = &exchanges.Positioning{Time: time.UnixMilli(ts)}
+			byTime[ts] = p
+		}
+		return p
+	}
+	for _, r := range ls {
+		v, err := strconv.ParseFloat(r.LongShortRatio, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse long/short ratio for %s: %w", symbol, err)
+		}
+		point(r.Timestamp).LongShort = v
+	}
+	for _, r := range taker {
+		v, err := strconv.ParseFloat(r.BuySellRatio, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse taker buy/sell ratio for %s: %w", symbol, err)
+		}
+		point(int64(r.Timestamp)).TakerBuySell = v
+	}
+
+	result := make([]exchanges.Positioning, 0, len(byTime))
+	for _, p := range byTime {
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Time.Before(result[j].Time) })
+	return result, nil
+}
@@ -0,0 +1,100 @@
+package binance
+
+import (
+	"context"
+	"time"
+
+	"1333/internal/exchanges"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// Provider реализует exchanges.MarketDataProvider для USDⓈ-M фьючерсов Binance
+type Provider struct {
+	client       *futures.Client
+	liquidations *LiquidationTracker
+}
+
+var (
+	_ exchanges.MarketDataProvider  = (*Provider)(nil)
+	_ exchanges.UsageReporter       = (*Provider)(nil)
+	_ exchanges.StatsUsageReporter  = (*Provider)(nil)
+	_ exchanges.BulkProvider        = (*Provider)(nil)
+	_ exchanges.LiquidationSource   = (*Provider)(nil)
+	_ exchanges.PositioningProvider = (*Provider)(nil)
+	_ exchanges.OIHistoryProvider   = (*Provider)(nil)
+	_ exchanges.InstrumentProvider  = (*Provider)(nil)
+)
+
+func NewProvider(client *futures.Client) *Provider {
+	return &Provider{
+		client:       client,
+		liquidations: NewLiquidationTracker(),
+	}
+}
+
+func (p *Provider) Name() string {
+	return "Binance"
+}
+
+func (p *Provider) Symbols(ctx context.Context) ([]string, error) {
+	return GetUSDMFuturesSymbols(p.client, ctx)
+}
+
+func (p *Provider) Instruments(ctx context.Context) ([]exchanges.Instrument, error) {
+	return GetUSDMFuturesInstruments(p.client, ctx)
+}
+
+func (p *Provider) Klines(ctx context.Context, symbol, interval string, limit int) ([]exchanges.Kline, error) {
+	return GetKlines(p.client, ctx, symbol, interval, limit)
+}
+
+func (p *Provider) OpenInterest(ctx context.Context, symbol string) (float64, error) {
+	return GetOpenInterest(p.client, ctx, symbol)
+}
+
+func (p *Provider) LastPrice(ctx context.Context, symbol string) (float64, error) {
+	return GetCurrentPrice(p.client, ctx, symbol)
+}
+
+func (p *Provider) FundingRate(ctx context.Context, symbol string) (exchanges.Funding, error) {
+	return GetFundingRate(p.client, ctx, symbol)
+}
+
+func (p *Provider) Tickers(ctx context.Context) (map[string]exchanges.Ticker, error) {
+	return GetAll24hTickers(p.client, ctx)
+}
+
+func (p *Provider) MarkPrices(ctx context.Context) (map[string]exchanges.Funding, error) {
+	return GetAllMarkPrices(p.client, ctx)
+}
+
+func (p *Provider) OpenInterests(ctx context.Context, symbols []string) map[string]float64 {
+	return GetOpenInterests(p.client, ctx, symbols)
+}
+
+func (p *Provider) StreamLiquidations(ctx context.Context) {
+	p.liquidations.Run(ctx)
+}
+
+func (p *Provider) Liquidations(symbol string, window time.Duration) exchanges.LiquidationStats {
+	return p.liquidations.Sum(symbol, window)
+}
+
+func (p *Provider) Positioning(ctx context.Context, symbol, period string, limit int) ([]exchanges.Positioning, error) {
+	return GetPositioning(p.client, ctx, symbol, period, limit)
+}
+
+func (p *Provider) OpenInterestHistory(ctx context.Context, symbol, period string, limit int) ([]exchanges.OIPoint, error) {
+	return GetOpenInterestHistory(p.client, ctx, symbol, period, limit)
+}
+
+// Usage — доля израсходованного минутного бюджета запросов к фьючерсам
+func (p *Provider) Usage() float64 {
+	return FuturesLimiter.UsageRatio()
+}
+
+// StatsUsage — доля израсходованного пятиминутного бюджета запросов к /futures/data
+func (p *Provider) StatsUsage() float64 {
+	return FuturesStatsLimiter.UsageRatio()
+}
@@ -0,0 +1,146 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"1333/internal/exchanges"
+
+	gobinance "github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+func NewSpotClient(apiKey, apiSecret string) *gobinance.Client {
+	client := gobinance.NewClient(apiKey, apiSecret)
+	client.HTTPClient = newLimitedHTTPClient(SpotLimiter)
+	return client
+}
+
+func GetSpotUSDTSymbols(client *gobinance.Client, ctx context.Context) ([]string, error) {
+	exchangeInfo, err := client.NewExchangeInfoService().Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spot exchange info: %w", err)
+	}
+
+	var symbols []string
+	for _, s := range exchangeInfo.Symbols {
+		if s.QuoteAsset == "USDT" && s.Status == "TRADING" && s.IsSpotTradingAllowed {
+			symbols = append(symbols, s.Symbol)
+		}
+	}
+	return symbols, nil
+}
+
+// GetSpot24hTickers возвращает суточную статистику по всем спотовым парам одним запросом
+func GetSpot24hTickers(client *gobinance.Client, ctx context.Context) (map[string]exchanges.Ticker, error) {
+	stats, err := client.NewListPriceChangeStatsService().Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spot 24h tickers: %w", err)
+	}
+
+	tickers := make(map[string]exchanges.Ticker, len(stats))
+	for _, st := range stats {
+		lastPrice, err1 := strconv.ParseFloat(st.LastPrice, 64)
+		changePercent, err2 := strconv.ParseFloat(st.PriceChangePercent, 64)
+		quoteVolume, err3 := strconv.ParseFloat(st.QuoteVolume, 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		tickers[st.Symbol] = exchanges.Ticker{
+			Symbol:             st.Symbol,
+			LastPrice:          lastPrice,
+			PriceChangePercent: changePercent,
+			QuoteVolume:        quoteVolume,
+		}
+	}
+	return tickers, nil
+}
+
+func GetSpotKlines(client *gobinance.Client, ctx context.Context, symbol, timeframe string, limit int) ([]exchanges.Kline, error) {
+	klines, err := client.NewKlinesService().
+		Symbol(symbol).
+		Interval(timeframe).
+		Limit(limit).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spot klines: %w", err)
+	}
+
+	result := make([]exchanges.Kline, 0, len(klines))
+	for _, k := range klines {
+		// Формат свечей спота совпадает с фьючерсным
+		kl, err := convertKline(&futures.Kline{
+			OpenTime:         k.OpenTime,
+			CloseTime:        k.CloseTime,
+			Open:             k.Open,
+			High:             k.High,
+			Low:              k.Low,
+			Close:            k.Close,
+			Volume:           k.Volume,
+			QuoteAssetVolume: k.QuoteAssetVolume,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse spot kline for %s: %w", symbol, err)
+		}
+		result = append(result, kl)
+	}
+	return result, nil
+}
+
+func GetSpotPrice(client *gobinance.Client, ctx context.Context, symbol string) (float64, error) {
+	prices, err := client.NewListPricesService().
+		Symbol(symbol).
+		Do(ctx)
+	if err != nil || len(prices) == 0 {
+		return 0, fmt.Errorf("failed to get spot price for %s: %w", symbol, err)
+	}
+	return strconv.ParseFloat(prices[0].Price, 64)
+}
+
+// SpotProvider реализует exchanges.MarketDataProvider для спота Binance.
+// OI и фандинга на споте нет, соответствующие методы возвращают ErrNotSupported.
+type SpotProvider struct {
+	client *gobinance.Client
+}
+
+var (
+	_ exchanges.MarketDataProvider = (*SpotProvider)(nil)
+	_ exchanges.TickerProvider     = (*SpotProvider)(nil)
+)
+
+func NewSpotProvider(client *gobinance.Client) *SpotProvider {
+	return &SpotProvider{client: client}
+}
+
+func (p *SpotProvider) Name() string {
+	return "Binance Spot"
+}
+
+func (p *SpotProvider) Symbols(ctx context.Context) ([]string, error) {
+	return GetSpotUSDTSymbols(p.client, ctx)
+}
+
+func (p *SpotProvider) Klines(ctx context.Context, symbol, interval string, limit int) ([]exchanges.Kline, error) {
+	return GetSpotKlines(p.client, ctx, symbol, interval, limit)
+}
+
+func (p *SpotProvider) OpenInterest(ctx context.Context, symbol string) (float64, error) {
+	return 0, exchanges.ErrNotSupported
+}
+
+func (p *SpotProvider) LastPrice(ctx context.Context, symbol string) (float64, error) {
+	return GetSpotPrice(p.client, ctx, symbol)
+}
+
+func (p *SpotProvider) Usage() float64 {
+	return SpotLimiter.UsageRatio()
+}
+
+func (p *SpotProvider) FundingRate(ctx context.Context, symbol string) (exchanges.Funding, error) {
+	return exchanges.Funding{}, exchanges.ErrNotSupported
+}
+
+func (p *SpotProvider) Tickers(ctx context.Context) (map[string]exchanges.Ticker, error) {
+	return GetSpot24hTickers(p.client, ctx)
+}
@@ -0,0 +1,177 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"1333/internal/exchanges"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+const (
+	// Binance допускает не более 200 стримов на одно combined-подключение
+	maxStreamsPerConn = 200
+
+	minReconnectDelay = time.Second
+	maxReconnectDelay = time.Minute
+
+	// Сколько свечей догружается при первом подключении, чтобы сразу были
+	// данные для метрик по истории (например, база объёма)
+	initialBackfillDepth = 30
+)
+
+var _ exchanges.StreamingProvider = (*Provider)(nil)
+
+type wsServeFunc func(errHandler futures.ErrHandler) (doneC, stopC chan struct{}, err error)
+
+// serveWithReconnect держит WebSocket-подключение до отмены ctx и переподключается
+// с экспоненциальной задержкой. onConnect вызывается после каждого подключения.
+func serveWithReconnect(ctx context.Context, name string, serve wsServeFunc, onConnect func()) {
+	delay := minReconnectDelay
+	for ctx.Err() == nil {
+		doneC, stopC, err := serve(func(err error) {
+			log.Printf("[WS %s] Ошибка стрима: %v", name, err)
+		})
+		if err != nil {
+			log.Printf("[WS %s] Ошибка подключения: %v, повтор через %s", name, err, delay)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			delay = min(delay*2, maxReconnectDelay)
+			continue
+		}
+
+		delay = minReconnectDelay
+		log.Printf("[WS %s] Подключено", name)
+		if onConnect != nil {
+			onConnect()
+		}
+
+		select {
+		case <-ctx.Done():
+			close(stopC)
+			<-doneC
+			return
+		case <-doneC:
+			log.Printf("[WS %s] Соединение закрыто, переподключение", name)
+		}
+	}
+}
+
+// StreamKlines подписывается на combined-стримы свечей, разбивая символы на
+// подключения по maxStreamsPerConn. После каждого (пере)подключения недостающие
+// свечи догружаются через REST, чтобы обрыв связи не оставлял дыр в истории.
+func (p *Provider) StreamKlines(ctx context.Context, symbols []string, interval string, handler func(exchanges.KlineUpdate)) {
+	var wg sync.WaitGroup
+	for start := 0; start < len(symbols); start += maxStreamsPerConn {
+		chunk := symbols[start:min(start+maxStreamsPerConn, len(symbols))]
+		name := fmt.Sprintf("kline_%s#%d", interval, start/maxStreamsPerConn)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.streamKlineChunk(ctx, name, chunk, interval, handler)
+		}()
+	}
+	wg.Wait()
+}
+
+func (p *Provider) streamKlineChunk(ctx context.Context, name string, symbols []string, interval string, handler func(exchanges.KlineUpdate)) {
+	pairs := make(map[string]string, len(symbols))
+	for _, sym := range symbols {
+		pairs[sym] = interval
+	}
+
+	var mu sync.Mutex
+	lastFinal := make(map[string]time.Time) // время открытия последней закрытой свечи
+
+	onEvent := func(e *futures.WsKlineEvent) {
+		k, err := convertWsKline(&e.Kline)
+		if err != nil {
+			log.Printf("[WS %s] Ошибка разбора свечи %s: %v", name, e.Symbol, err)
+			return
+		}
+		if e.Kline.IsFinal {
+			mu.Lock()
+			lastFinal[e.Symbol] = k.OpenTime
+			mu.Unlock()
+		}
+		handler(exchanges.KlineUpdate{Symbol: e.Symbol, Interval: interval, Kline: k, Final: e.Kline.IsFinal})
+	}
+
+	backfill := func() {
+		for _, sym := range symbols {
+			if ctx.Err() != nil {
+				return
+			}
+			mu.Lock()
+			since, ok := lastFinal[sym]
+			mu.Unlock()
+
+			var klines []exchanges.Kline
+			var err error
+			if ok {
+				klines, err = GetKlinesSince(p.client, ctx, sym, interval, since.Add(time.Millisecond))
+			} else {
+				klines, err = GetKlines(p.client, ctx, sym, interval, initialBackfillDepth)
+			}
+			if err != nil {
+				log.Printf("[WS %s] Ошибка догрузки свечей для %s: %v", name, sym, err)
+				continue
+			}
+
+			now := time.Now()
+			for _, k := range klines {
+				final := k.CloseTime.Before(now)
+				if final {
+					mu.Lock()
+					if k.OpenTime.After(lastFinal[sym]) {
+						lastFinal[sym] = k.OpenTime
+					}
+					mu.Unlock()
+				}
+				handler(exchanges.KlineUpdate{Symbol: sym, Interval: interval, Kline: k, Final: final})
+			}
+		}
+	}
+
+	serveWithReconnect(ctx, name, func(errHandler futures.ErrHandler) (chan struct{}, chan struct{}, error) {
+		return futures.WsCombinedKlineServe(pairs, onEvent, errHandler)
+	}, backfill)
+}
+
+// StreamMarkPrices подписывается на !markPrice@arr@1s — mark price всех контрактов раз в секунду
+func (p *Provider) StreamMarkPrices(ctx context.Context, handler func(symbol string, price float64)) {
+	onEvent := func(event futures.WsAllMarkPriceEvent) {
+		for _, e := range event {
+			price, err := strconv.ParseFloat(e.MarkPrice, 64)
+			if err != nil {
+				continue
+			}
+			handler(e.Symbol, price)
+		}
+	}
+
+	serveWithReconnect(ctx, "markPrice", func(errHandler futures.ErrHandler) (chan struct{}, chan struct{}, error) {
+		return futures.WsAllMarkPriceServeWithRate(time.Second, onEvent, errHandler)
+	}, nil)
+}
+
+func convertWsKline(k *futures.WsKline) (exchanges.Kline, error) {
+	return convertKline(&futures.Kline{
+		OpenTime:         k.StartTime,
+		CloseTime:        k.EndTime,
+		Open:             k.Open,
+		High:             k.High,
+		Low:              k.Low,
+		Close:            k.Close,
+		Volume:           k.Volume,
+		QuoteAssetVolume: k.QuoteVolume,
+	})
+}
@@ -0,0 +1,351 @@
+package bybit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"1333/internal/exchanges"
+)
+
+const baseURL = "https://api.bybit.com"
+
+// Client — минимальный клиент публичного REST API Bybit v5
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// Limiter — общий лимит публичного API Bybit: 600 запросов за 5 секунд на IP
+var Limiter = exchanges.NewRateLimiter("Bybit", 600, 5*time.Second)
+
+func NewClient() *Client {
+	return &Client{
+		BaseURL: baseURL,
+		HTTPClient: exchanges.NewRateLimitedClient(10*time.Second, func(string) *exchanges.RateLimiter {
+			return Limiter
+		}),
+	}
+}
+
+type response struct {
+	RetCode int             `json:"retCode"`
+	RetMsg  string          `json:"retMsg"`
+	Result  json.RawMessage `json:"result"`
+}
+
+func (c *Client) get(ctx context.Context, path string, params url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	var r response
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return fmt.Errorf("failed to decode %s response (status %d): %w", path, res.StatusCode, err)
+	}
+	if r.RetCode != 0 {
+		return fmt.Errorf("bybit %s: %d %s", path, r.RetCode, r.RetMsg)
+	}
+	return json.Unmarshal(r.Result, out)
+}
+
+type instrument struct {
+	Symbol       string `json:"symbol"`
+	ContractType string `json:"contractType"`
+	Status       string `json:"status"`
+	QuoteCoin    string `json:"quoteCoin"`
+	LaunchTime   string `json:"launchTime"` // мс
+}
+
+func GetLinearSymbols(client *Client, ctx context.Context) ([]string, error) {
+	instruments, err := GetLinearInstruments(client, ctx)
+	if err != nil {
+		return nil, err
+	}
+	var symbols []string
+	for _, in := range instruments {
+		if in.Status == exchanges.StatusTrading {
+			symbols = append(symbols, in.Symbol)
+		}
+	}
+	return symbols, nil
+}
+
+// GetLinearInstruments возвращает USDT-перпетуалы во всех статусах с датой листинга
+func GetLinearInstruments(client *Client, ctx context.Context) ([]exchanges.Instrument, error) {
+	var instruments []exchanges.Instrument
+	cursor := ""
+	for {
+		params := url.Values{"category": {"linear"}, "limit": {"1000"}}
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+		var result struct {
+			List           []instrument `json:"list"`
+			NextPageCursor string       `json:"nextPageCursor"`
+		}
+		if err := client.get(ctx, "/v5/market/instruments-info", params, &result); err != nil {
+			return nil, fmt.Errorf("failed to get instruments info: %w", err)
+		}
+		for _, s := range result.List {
+			if s.ContractType == "LinearPerpetual" && s.QuoteCoin == "USDT" {
+				instruments = append(instruments, exchanges.Instrument{
+					Symbol:   s.Symbol,
+					Status:   contractStatus(s.Status),
+					ListedAt: parseMillis(s.LaunchTime),
+				})
+			}
+		}
+		if result.NextPageCursor == "" {
+			return instruments, nil
+		}
+		cursor = result.NextPageCursor
+	}
+}
+
+// contractStatus переводит статус контракта Bybit в общий
+func contractStatus(status string) string {
+	switch status {
+	case "Trading":
+		return exchanges.StatusTrading
+	case "PreLaunch":
+		return exchanges.StatusPending
+	case "Settling":
+		return exchanges.StatusSettling
+	case "Delivering":
+		return exchanges.StatusDelivering
+	}
+	return exchanges.StatusClosed
+}
+
+// parseMillis разбирает время в миллисекундах, переданное строкой; при ошибке — нулевое время
+func parseMillis(s string) time.Time {
+	ms, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || ms <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// Интервалы свечей Bybit; других площадка не принимает
+var klineIntervals = map[string]string{
+	"1m": "1", "3m": "3", "5m": "5", "15m": "15", "30m": "30",
+	"1h": "60", "2h": "120", "4h": "240", "6h": "360", "12h": "720",
+	"1d": "D",
+}
+
+// klineInterval переводит таймфрейм в формате Binance ("5m", "1h", "1d") в формат Bybit ("5", "60", "D")
+func klineInterval(timeframe string) (string, error) {
+	interval, ok := klineIntervals[timeframe]
+	if !ok {
+		return "", fmt.Errorf("unsupported timeframe %q", timeframe)
+	}
+	return interval, nil
+}
+
+func GetKlines(client *Client, ctx context.Context, symbol, timeframe string, limit int) ([]exchanges.Kline, error) {
+	interval, err := klineInterval(timeframe)
+	if err != nil {
+		return nil, err
+	}
+	duration, _ := exchanges.TimeframeDuration(timeframe)
+	params := url.Values{
+		"category": {"linear"},
+		"symbol":   {symbol},
+		"interval": {interval},
+		"limit":    {strconv.Itoa(limit)},
+	}
+	var result struct {
+		List [][]string `json:"list"`
+	}
+	if err := client.get(ctx, "/v5/market/kline", params, &result); err != nil {
+		return nil, fmt.Errorf("failed to get klines: %w", err)
+	}
+
+	// Bybit отдаёт свечи от новых к старым
+	klines := make([]exchanges.Kline, 0, len(result.List))
+	for i := len(result.List) - 1; i >= 0; i-- {
+		row := result.List[i]
+		if len(row) < 7 {
+			return nil, fmt.Errorf("malformed kline for %s", symbol)
+		}
+		var f [6]float64
+		for j := range f {
+			if f[j], err = strconv.ParseFloat(row[j+1], 64); err != nil {
+				return nil, fmt.Errorf("failed to parse kline for %s: %w", symbol, err)
+			}
+		}
+		start, err := strconv.ParseInt(row[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse kline start for %s: %w", symbol, err)
+		}
+		openTime := time.UnixMilli(start)
+		klines = append(klines, exchanges.Kline{
+			OpenTime:    openTime,
+			CloseTime:   openTime.Add(duration - time.Millisecond),
+			Open:        f[0],
+			High:        f[1],
+			Low:         f[2],
+			Close:       f[3],
+			Volume:      f[4],
+			QuoteVolume: f[5],
+		})
+	}
+	return klines, nil
+}
+
+type ticker struct {
+	Symbol          string `json:"symbol"`
+	LastPrice       string `json:"lastPrice"`
+	MarkPrice       string `json:"markPrice"`
+	FundingRate     string `json:"fundingRate"`
+	NextFundingTime string `json:"nextFundingTime"`
+	OpenInterest    string `json:"openInterest"`
+	Price24hPcnt    string `json:"price24hPcnt"`
+	Turnover24h     string `json:"turnover24h"` // объём за 24ч в USDT
+}
+
+func getTicker(client *Client, ctx context.Context, symbol string) (*ticker, error) {
+	params := url.Values{"category": {"linear"}, "symbol": {symbol}}
+	var result struct {
+		List []ticker `json:"list"`
+	}
+	if err := client.get(ctx, "/v5/market/tickers", params, &result); err != nil {
+		return nil, fmt.Errorf("failed to get ticker for %s: %w", symbol, err)
+	}
+	if len(result.List) == 0 {
+		return nil, fmt.Errorf("no ticker for %s", symbol)
+	}
+	return &result.List[0], nil
+}
+
+// getAllTickers запрашивает тикеры всех линейных контрактов: в них же OI и фандинг
+func getAllTickers(client *Client, ctx context.Context) ([]ticker, error) {
+	var result struct {
+		List []ticker `json:"list"`
+	}
+	if err := client.get(ctx, "/v5/market/tickers", url.Values{"category": {"linear"}}, &result); err != nil {
+		return nil, fmt.Errorf("failed to get tickers: %w", err)
+	}
+	return result.List, nil
+}
+
+// GetTickers возвращает суточную статистику по всем линейным контрактам одним запросом
+func GetTickers(client *Client, ctx context.Context) (map[string]exchanges.Ticker, error) {
+	list, err := getAllTickers(client, ctx)
+	if err != nil {
+		return nil, err
+	}
+	tickers := make(map[string]exchanges.Ticker, len(list))
+	for _, t := range list {
+		last, err1 := strconv.ParseFloat(t.LastPrice, 64)
+		turnover, err2 := strconv.ParseFloat(t.Turnover24h, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		// price24hPcnt — доля, а не проценты
+		change, _ := strconv.ParseFloat(t.Price24hPcnt, 64)
+		tickers[t.Symbol] = exchanges.Ticker{
+			Symbol:             t.Symbol,
+			LastPrice:          last,
+			PriceChangePercent: change * 100,
+			QuoteVolume:        turnover,
+		}
+	}
+	return tickers, nil
+}
+
+// GetAllMarkPrices возвращает mark price и ставку финансирования всех контрактов одним запросом
+func GetAllMarkPrices(client *Client, ctx context.Context) (map[string]exchanges.Funding, error) {
+	list, err := getAllTickers(client, ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]exchanges.Funding, len(list))
+	for _, t := range list {
+		f, err := parseFunding(&t)
+		if err != nil {
+			continue // у контрактов в поставке фандинга нет
+		}
+		result[t.Symbol] = f
+	}
+	return result, nil
+}
+
+// GetAllOpenInterests возвращает OI по символам из одного запроса тикеров.
+// Символы без OI в ответе в результат не попадают.
+func GetAllOpenInterests(client *Client, ctx context.Context, symbols []string) map[string]float64 {
+	list, err := getAllTickers(client, ctx)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get Bybit open interest: %v", err)
+		return nil
+	}
+	bySymbol := make(map[string]string, len(list))
+	for _, t := range list {
+		bySymbol[t.Symbol] = t.OpenInterest
+	}
+	result := make(map[string]float64, len(symbols))
+	for _, sym := range symbols {
+		if oi, err := strconv.ParseFloat(bySymbol[sym], 64); err == nil {
+			result[sym] = oi
+		}
+	}
+	return result
+}
+
+func GetOpenInterest(client *Client, ctx context.Context, symbol string) (float64, error) {
+	t, err := getTicker(client, ctx, symbol)
+	if err != nil {
+		return 0, err
+	}
+	oi, err := strconv.ParseFloat(t.OpenInterest, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse open interest value for %s: %w", symbol, err)
+	}
+	return oi, nil
+}
+
+func GetCurrentPrice(client *Client, ctx context.Context, symbol string) (float64, error) {
+	t, err := getTicker(client, ctx, symbol)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(t.LastPrice, 64)
+}
+
+func GetFundingRate(client *Client, ctx context.Context, symbol string) (exchanges.Funding, error) {
+	t, err := getTicker(client, ctx, symbol)
+	if err != nil {
+		return exchanges.Funding{}, err
+	}
+	return parseFunding(t)
+}
+
+func parseFunding(t *ticker) (exchanges.Funding, error) {
+	rate, err := strconv.ParseFloat(t.FundingRate, 64)
+	if err != nil {
+		return exchanges.Funding{}, fmt.Errorf("failed to parse funding rate for %s: %w", t.Symbol, err)
+	}
+	markPrice, err := strconv.ParseFloat(t.MarkPrice, 64)
+	if err != nil {
+		return exchanges.Funding{}, fmt.Errorf("failed to parse mark price for %s: %w", t.Symbol, err)
+	}
+	next, _ := strconv.ParseInt(t.NextFundingTime, 10, 64)
+	return exchanges.Funding{
+		Symbol:          t.Symbol,
+		Rate:            rate,
+		MarkPrice:       markPrice,
+		NextFundingTime: time.UnixMilli(next),
+	}, nil
+}
@@ -0,0 +1,71 @@
+package bybit
+
+import (
+	"context"
+
+	"1333/internal/exchanges"
+)
+
+// Provider реализует exchanges.MarketDataProvider для линейных бессрочных контрактов Bybit.
+// Символы совпадают с Binance ("BTCUSDT"). Тикеры Bybit несут OI, фандинг и mark price,
+// поэтому хаб получает их по всем контрактам одним запросом, посимвольно — только свечи.
+type Provider struct {
+	client *Client
+}
+
+var (
+	_ exchanges.MarketDataProvider = (*Provider)(nil)
+	_ exchanges.UsageReporter      = (*Provider)(nil)
+	_ exchanges.BulkProvider       = (*Provider)(nil)
+	_ exchanges.InstrumentProvider = (*Provider)(nil)
+)
+
+func NewProvider(client *Client) *Provider {
+	return &Provider{client: client}
+}
+
+func (p *Provider) Name() string {
+	return "Bybit"
+}
+
+func (p *Provider) Symbols(ctx context.Context) ([]string, error) {
+	return GetLinearSymbols(p.client, ctx)
+}
+
+func (p *Provider) Instruments(ctx context.Context) ([]exchanges.Instrument, error) {
+	return GetLinearInstruments(p.client, ctx)
+}
+
+func (p *Provider) Klines(ctx context.Context, symbol, interval string, limit int) ([]exchanges.Kline, error) {
+	return GetKlines(p.client, ctx, symbol, interval, limit)
+}
+
+// OpenInterest берёт OI из тикера символа
+func (p *Provider) OpenInterest(ctx context.Context, symbol string) (float64, error) {
+	return GetOpenInterest(p.client, ctx, symbol)
+}
+
+func (p *Provider) LastPrice(ctx context.Context, symbol string) (float64, error) {
+	return GetCurrentPrice(p.client, ctx, symbol)
+}
+
+func (p *Provider) FundingRate(ctx context.Context, symbol string) (exchanges.Funding, error) {
+	return GetFundingRate(p.client, ctx, symbol)
+}
+
+func (p *Provider) Tickers(ctx context.Context) (map[string]exchanges.Ticker, error) {
+	return GetTickers(p.client, ctx)
+}
+
+func (p *Provider) MarkPrices(ctx context.Context) (map[string]exchanges.Funding, error) {
+	return GetAllMarkPrices(p.client, ctx)
+}
+
+func (p *Provider) OpenInterests(ctx context.Context, symbols []string) map[string]float64 {
+	return GetAllOpenInterests(p.client, ctx, symbols)
+}
+
+// Usage — доля израсходованного пятисекундного лимита запросов к Bybit
+func (p *Provider) Usage() float64 {
+	return Limiter.UsageRatio()
+}
@@ -0,0 +1,179 @@
+package exchanges
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Kline — свеча в формате, не зависящем от биржи
+type Kline struct {
+	OpenTime    time.Time
+	CloseTime   time.Time
+	Open        float64
+	High        float64
+	Low         float64
+	Close       float64
+	Volume      float64
+	QuoteVolume float64
+}
+
+// Timeframes — таймфреймы свечей, которые отдают все поддерживаемые биржи.
+// Пользователь может выбрать только их.
+var Timeframes = []string{"1m", "3m", "5m", "15m", "30m", "1h", "2h", "4h", "6h", "12h", "1d"}
+
+var timeframeDurations = map[string]time.Duration{
+	"1m": time.Minute, "3m": 3 * time.Minute, "5m": 5 * time.Minute, "15m": 15 * time.Minute,
+	"30m": 30 * time.Minute, "1h": time.Hour, "2h": 2 * time.Hour, "4h": 4 * time.Hour,
+	"6h": 6 * time.Hour, "12h": 12 * time.Hour, "1d": 24 * time.Hour,
+}
+
+// TimeframeDuration возвращает длительность свечи; ok=false для таймфрейма не из Timeframes
+func TimeframeDuration(tf string) (time.Duration, bool) {
+	d, ok := timeframeDurations[tf]
+	return d, ok
+}
+
+// Funding — текущая ставка финансирования по бессрочному контракту
+type Funding struct {
+	Symbol          string
+	Rate            float64
+	MarkPrice       float64
+	NextFundingTime time.Time
+}
+
+// Ticker — суточная статистика по символу
+type Ticker struct {
+	Symbol             string
+	LastPrice          float64
+	PriceChangePercent float64
+	QuoteVolume        float64
+}
+
+// MarketDataProvider описывает источник рыночных данных одной биржи.
+// Монитор работает только через этот интерфейс, поэтому новые площадки
+// и фейки для тестов подключаются без изменения логики алертов.
+type MarketDataProvider interface {
+	// Name возвращает название площадки для логов и текстов алертов
+	Name() string
+	// Symbols возвращает список торгуемых бессрочных USDT-контрактов
+	Symbols(ctx context.Context) ([]string, error)
+	// Klines возвращает последние limit свечей, от старых к новым
+	Klines(ctx context.Context, symbol, interval string, limit int) ([]Kline, error)
+	OpenInterest(ctx context.Context, symbol string) (float64, error)
+	LastPrice(ctx context.Context, symbol string) (float64, error)
+	FundingRate(ctx context.Context, symbol string) (Funding, error)
+}
+
+// TickerProvider реализуют площадки, отдающие суточную статистику по всем символам одним запросом
+type TickerProvider interface {
+	Tickers(ctx context.Context) (map[string]Ticker, error)
+}
+
+// BulkProvider реализуют площадки, умеющие отдавать данные сразу по всем символам.
+// Хаб использует его вместо посимвольных запросов, если площадка его поддерживает.
+type BulkProvider interface {
+	TickerProvider
+	MarkPrices(ctx context.Context) (map[string]Funding, error)
+	// OpenInterests возвращает OI по символам; неудавшиеся символы пропускаются
+	OpenInterests(ctx context.Context, symbols []string) map[string]float64
+}
+
+// LiquidationStats — номинал ликвидаций в USDT за окно
+type LiquidationStats struct {
+	Long  float64 // ликвидированные длинные позиции
+	Short float64 // ликвидированные короткие позиции
+}
+
+func (l LiquidationStats) Total() float64 {
+	return l.Long + l.Short
+}
+
+// LiquidationSource реализуют площадки с потоком принудительных ликвидаций
+type LiquidationSource interface {
+	// StreamLiquidations блокируется до отмены ctx, накапливая ликвидации
+	StreamLiquidations(ctx context.Context)
+	// Liquidations возвращает сумму ликвидаций по символу за последние window
+	Liquidations(symbol string, window time.Duration) LiquidationStats
+}
+
+// Positioning — позиционирование участников рынка по символу на момент Time
+type Positioning struct {
+	Time         time.Time
+	LongShort    float64 // отношение аккаунтов в лонге к аккаунтам в шорте
+	TakerBuySell float64 // отношение объёма покупок тейкеров к объёму продаж
+}
+
+// PositioningProvider реализуют площадки, публикующие статистику позиционирования
+type PositioningProvider interface {
+	// Positioning возвращает limit последних точек с шагом period, от старых к новым
+	Positioning(ctx context.Context, symbol, period string, limit int) ([]Positioning, error)
+}
+
+// OIPoint — значение открытого интереса на момент Time
+type OIPoint struct {
+	Time time.Time
+	OI   float64
+}
+
+// OIHistoryProvider реализуют площадки, отдающие историю открытого интереса.
+// По ней монитор заполняет историю OI после рестарта, не дожидаясь новых замеров.
+type OIHistoryProvider interface {
+	// OpenInterestHistory возвращает limit последних точек с шагом period, от старых к новым
+	OpenInterestHistory(ctx context.Context, symbol, period string, limit int) ([]OIPoint, error)
+}
+
+// Статусы контрактов, единые для всех площадок
+const (
+	StatusTrading    = "trading"
+	StatusPending    = "pending"    // объявлен, торги ещё не начались
+	StatusSuspended  = "suspended"  // торги приостановлены
+	StatusSettling   = "settling"   // расчёт перед делистингом
+	StatusDelivering = "delivering" // поставка/экспирация
+	StatusClosed     = "closed"
+)
+
+// Instrument — контракт площадки
+type Instrument struct {
+	Symbol   string
+	Status   string
+	ListedAt time.Time // нулевое, если площадка не сообщает дату листинга
+}
+
+// InstrumentProvider реализуют площадки, отдающие описание контрактов, а не только тикеры
+type InstrumentProvider interface {
+	// Instruments возвращает контракты в любом статусе; Symbols — только торгуемые
+	Instruments(ctx context.Context) ([]Instrument, error)
+}
+
+// UsageReporter реализуют площадки с учётом лимита запросов. Монитор по нему
+// решает, когда пропустить второстепенные запросы, чтобы не получить бан по IP.
+type UsageReporter interface {
+	// Usage возвращает долю израсходованного бюджета запросов, от 0 до 1
+	Usage() float64
+}
+
+// StatsUsageReporter реализуют площадки, у которых статистика (позиционирование,
+// история OI) лимитируется отдельно от остальных запросов
+type StatsUsageReporter interface {
+	// StatsUsage возвращает долю израсходованного бюджета запросов статистики, от 0 до 1
+	StatsUsage() float64
+}
+
+// KlineUpdate — обновление свечи, пришедшее из стрима
+type KlineUpdate struct {
+	Symbol   string
+	Interval string
+	Kline    Kline
+	Final    bool // свеча закрыта
+}
+
+// StreamingProvider реализуют площадки, которые умеют отдавать данные по WebSocket.
+// Методы блокируются до отмены ctx и сами переподключаются при обрывах связи.
+type StreamingProvider interface {
+	StreamKlines(ctx context.Context, symbols []string, interval string, handler func(KlineUpdate))
+	StreamMarkPrices(ctx context.Context, handler func(symbol string, price float64))
+}
+
+// ErrNotSupported возвращают площадки, у которых нет запрошенной метрики (например, OI на споте)
+var ErrNotSupported = errors.New("not supported by this market")
@@ -0,0 +1,95 @@
+// Package exchangestest — площадка в памяти для тестов кода поверх exchanges
+package exchangestest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"1333/internal/exchanges"
+)
+
+// Fake — площадка в памяти для тестов монитора: отдаёт ровно то, что в неё положили
+type Fake struct {
+	name    string
+	symbols []string
+
+	mu      sync.Mutex
+	klines  map[string][]exchanges.Kline // символ + таймфрейм -> свечи от старых к новым
+	oi      map[string]float64
+	funding map[string]exchanges.Funding
+}
+
+var _ exchanges.MarketDataProvider = (*Fake)(nil)
+
+func NewFake(name string, symbols ...string) *Fake {
+	return &Fake{
+		name:    name,
+		symbols: symbols,
+		klines:  make(map[string][]exchanges.Kline),
+		oi:      make(map[string]float64),
+		funding: make(map[string]exchanges.Funding),
+	}
+}
+
+func (f *Fake) SetKlines(symbol, interval string, klines []exchanges.Kline) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.klines[symbol+"/"+interval] = klines
+}
+
+func (f *Fake) SetOpenInterest(symbol string, oi float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.oi[symbol] = oi
+}
+
+func (f *Fake) SetFunding(symbol string, funding exchanges.Funding) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.funding[symbol] = funding
+}
+
+func (f *Fake) Name() string {
+	return f.name
+}
+
+func (f *Fake) Symbols(ctx context.Context) ([]string, error) {
+	return f.symbols, nil
+}
+
+func (f *Fake) Klines(ctx context.Context, symbol, interval string, limit int) ([]exchanges.Kline, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	klines := f.klines[symbol+"/"+interval]
+	return append([]exchanges.Kline(nil), klines[max(len(klines)-limit, 0):]...), nil
+}
+
+func (f *Fake) OpenInterest(ctx context.Context, symbol string) (float64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.oi[symbol], nil
+}
+
+// LastPrice — закрытие последней свечи любого таймфрейма
+func (f *Fake) LastPrice(ctx context.Context, symbol string) (float64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for key, klines := range f.klines {
+		if len(klines) > 0 && strings.HasPrefix(key, symbol+"/") {
+			return klines[len(klines)-1].Close, nil
+		}
+	}
+	return 0, fmt.Errorf("нет свечей по %s", symbol)
+}
+
+func (f *Fake) FundingRate(ctx context.Context, symbol string) (exchanges.Funding, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	funding, ok := f.funding[symbol]
+	if !ok {
+		return exchanges.Funding{}, fmt.Errorf("нет фандинга по %s", symbol)
+	}
+	return funding, nil
+}
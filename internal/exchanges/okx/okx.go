@@ -0,0 +1,380 @@
+package okx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"1333/internal/exchanges"
+)
+
+const baseURL = "https://www.okx.com"
+
+// Client — минимальный клиент публичного REST API OKX v5
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// У OKX лимит на IP свой у каждого эндпоинта, окно — 2 секунды
+var (
+	limiters = map[string]*exchanges.RateLimiter{
+		"/api/v5/market/candles":       exchanges.NewRateLimiter("OKX candles", 40, 2*time.Second),
+		"/api/v5/public/open-interest": exchanges.NewRateLimiter("OKX open-interest", 20, 2*time.Second),
+		"/api/v5/public/funding-rate":  exchanges.NewRateLimiter("OKX funding-rate", 10, 2*time.Second),
+		"/api/v5/public/mark-price":    exchanges.NewRateLimiter("OKX mark-price", 10, 2*time.Second),
+	}
+	defaultLimiter = exchanges.NewRateLimiter("OKX", 20, 2*time.Second)
+)
+
+func limiterFor(path string) *exchanges.RateLimiter {
+	if l, ok := limiters[path]; ok {
+		return l
+	}
+	return defaultLimiter
+}
+
+// Usage — наибольшая доля израсходованного лимита среди эндпоинтов OKX
+func Usage() float64 {
+	usage := defaultLimiter.UsageRatio()
+	for _, l := range limiters {
+		usage = max(usage, l.UsageRatio())
+	}
+	return usage
+}
+
+func NewClient() *Client {
+	return &Client{
+		BaseURL:    baseURL,
+		HTTPClient: exchanges.NewRateLimitedClient(10*time.Second, limiterFor),
+	}
+}
+
+type response struct {
+	Code string          `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+func (c *Client) get(ctx context.Context, path string, params url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	var r response
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return fmt.Errorf("failed to decode %s response (status %d): %w", path, res.StatusCode, err)
+	}
+	if r.Code != "0" {
+		return fmt.Errorf("okx %s: %s %s", path, r.Code, r.Msg)
+	}
+	return json.Unmarshal(r.Data, out)
+}
+
+type instrument struct {
+	InstID    string `json:"instId"`
+	SettleCcy string `json:"settleCcy"`
+	CtType    string `json:"ctType"`
+	State     string `json:"state"`
+	ListTime  string `json:"listTime"` // мс
+}
+
+// GetUSDTSwapSymbols возвращает бессрочные USDT-маржинальные свопы в нативном виде ("BTC-USDT-SWAP")
+func GetUSDTSwapSymbols(client *Client, ctx context.Context) ([]string, error) {
+	instruments, err := GetUSDTSwapInstruments(client, ctx)
+	if err != nil {
+		return nil, err
+	}
+	var symbols []string
+	for _, in := range instruments {
+		if in.Status == exchanges.StatusTrading {
+			symbols = append(symbols, in.Symbol)
+		}
+	}
+	return symbols, nil
+}
+
+// GetUSDTSwapInstruments возвращает USDT-свопы во всех статусах с датой листинга
+func GetUSDTSwapInstruments(client *Client, ctx context.Context) ([]exchanges.Instrument, error) {
+	var list []instrument
+	if err := client.get(ctx, "/api/v5/public/instruments", url.Values{"instType": {"SWAP"}}, &list); err != nil {
+		return nil, fmt.Errorf("failed to get instruments: %w", err)
+	}
+
+	var instruments []exchanges.Instrument
+	for _, s := range list {
+		if s.SettleCcy == "USDT" && s.CtType == "linear" {
+			instruments = append(instruments, exchanges.Instrument{
+				Symbol:   s.InstID,
+				Status:   contractStatus(s.State),
+				ListedAt: parseMillis(s.ListTime),
+			})
+		}
+	}
+	return instruments, nil
+}
+
+// contractStatus переводит состояние инструмента OKX в общий статус
+func contractStatus(state string) string {
+	switch state {
+	case "live":
+		return exchanges.StatusTrading
+	case "preopen":
+		return exchanges.StatusPending
+	case "suspend":
+		return exchanges.StatusSuspended
+	}
+	return exchanges.StatusClosed
+}
+
+// parseMillis разбирает время в миллисекундах, переданное строкой; при ошибке — нулевое время
+func parseMillis(s string) time.Time {
+	ms, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || ms <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// barSize переводит таймфрейм в формате Binance ("5m", "1h", "1d") в формат OKX ("5m", "1H", "1D")
+func barSize(timeframe string) string {
+	if strings.HasSuffix(timeframe, "m") {
+		return timeframe
+	}
+	return strings.ToUpper(timeframe)
+}
+
+func GetKlines(client *Client, ctx context.Context, symbol, timeframe string, limit int) ([]exchanges.Kline, error) {
+	duration, ok := exchanges.TimeframeDuration(timeframe)
+	if !ok {
+		return nil, fmt.Errorf("unsupported timeframe %q", timeframe)
+	}
+	params := url.Values{
+		"instId": {symbol},
+		"bar":    {barSize(timeframe)},
+		"limit":  {strconv.Itoa(limit)},
+	}
+	var rows [][]string
+	if err := client.get(ctx, "/api/v5/market/candles", params, &rows); err != nil {
+		return nil, fmt.Errorf("failed to get klines: %w", err)
+	}
+
+	// OKX отдаёт свечи от новых к старым: ts, o, h, l, c, vol, volCcy, volCcyQuote, confirm
+	klines := make([]exchanges.Kline, 0, len(rows))
+	for i := len(rows) - 1; i >= 0; i-- {
+		row := rows[i]
+		if len(row) < 8 {
+			return nil, fmt.Errorf("malformed kline for %s", symbol)
+		}
+		ts, err := strconv.ParseInt(row[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse kline start for %s: %w", symbol, err)
+		}
+		var f [7]float64
+		for j := range f {
+			if f[j], err = strconv.ParseFloat(row[j+1], 64); err != nil {
+				return nil, fmt.Errorf("failed to parse kline for %s: %w", symbol, err)
+			}
+		}
+		openTime := time.UnixMilli(ts)
+		klines = append(klines, exchanges.Kline{
+			OpenTime:    openTime,
+			CloseTime:   openTime.Add(duration - time.Millisecond),
+			Open:        f[0],
+			High:        f[1],
+			Low:         f[2],
+			Close:       f[3],
+			Volume:      f[5],
+			QuoteVolume: f[6],
+		})
+	}
+	return klines, nil
+}
+
+// GetOpenInterest возвращает OI в монетах базового актива, как и у Binance
+func GetOpenInterest(client *Client, ctx context.Context, symbol string) (float64, error) {
+	params := url.Values{"instType": {"SWAP"}, "instId": {symbol}}
+	var data []struct {
+		OiCcy string `json:"oiCcy"`
+	}
+	if err := client.get(ctx, "/api/v5/public/open-interest", params, &data); err != nil {
+		return 0, fmt.Errorf("failed to get open interest for %s: %w", symbol, err)
+	}
+	if len(data) == 0 {
+		return 0, fmt.Errorf("no open interest for %s", symbol)
+	}
+	oi, err := strconv.ParseFloat(data[0].OiCcy, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse open interest value for %s: %w", symbol, err)
+	}
+	return oi, nil
+}
+
+// GetAllOpenInterests возвращает OI по символам из одного запроса по всем свопам.
+// Символы без OI в ответе в результат не попадают.
+func GetAllOpenInterests(client *Client, ctx context.Context, symbols []string) map[string]float64 {
+	var data []struct {
+		InstID string `json:"instId"`
+		OiCcy  string `json:"oiCcy"`
+	}
+	if err := client.get(ctx, "/api/v5/public/open-interest", url.Values{"instType": {"SWAP"}}, &data); err != nil {
+		log.Printf("[ERROR] Failed to get OKX open interest: %v", err)
+		return nil
+	}
+	bySymbol := make(map[string]string, len(data))
+	for _, d := range data {
+		bySymbol[d.InstID] = d.OiCcy
+	}
+	result := make(map[string]float64, len(symbols))
+	for _, sym := range symbols {
+		if oi, err := strconv.ParseFloat(bySymbol[sym], 64); err == nil {
+			result[sym] = oi
+		}
+	}
+	return result
+}
+
+// GetTickers возвращает суточную статистику по всем USDT-свопам одним запросом
+func GetTickers(client *Client, ctx context.Context) (map[string]exchanges.Ticker, error) {
+	var data []struct {
+		InstID    string `json:"instId"`
+		Last      string `json:"last"`
+		Open24h   string `json:"open24h"`
+		VolCcy24h string `json:"volCcy24h"` // для свопов — в базовой монете
+	}
+	if err := client.get(ctx, "/api/v5/market/tickers", url.Values{"instType": {"SWAP"}}, &data); err != nil {
+		return nil, fmt.Errorf("failed to get tickers: %w", err)
+	}
+	tickers := make(map[string]exchanges.Ticker, len(data))
+	for _, t := range data {
+		if !strings.HasSuffix(t.InstID, "-USDT-SWAP") {
+			continue
+		}
+		last, err1 := strconv.ParseFloat(t.Last, 64)
+		vol, err2 := strconv.ParseFloat(t.VolCcy24h, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		var change float64
+		if open, err := strconv.ParseFloat(t.Open24h, 64); err == nil && open > 0 {
+			change = (last - open) / open * 100
+		}
+		tickers[t.InstID] = exchanges.Ticker{
+			Symbol:             t.InstID,
+			LastPrice:          last,
+			PriceChangePercent: change,
+			QuoteVolume:        vol * last,
+		}
+	}
+	return tickers, nil
+}
+
+func GetCurrentPrice(client *Client, ctx context.Context, symbol string) (float64, error) {
+	var data []struct {
+		Last string `json:"last"`
+	}
+	if err := client.get(ctx, "/api/v5/market/ticker", url.Values{"instId": {symbol}}, &data); err != nil {
+		return 0, fmt.Errorf("failed to get price for %s: %w", symbol, err)
+	}
+	if len(data) == 0 {
+		return 0, fmt.Errorf("no ticker for %s", symbol)
+	}
+	return strconv.ParseFloat(data[0].Last, 64)
+}
+
+// GetAllMarkPrices возвращает ставку финансирования и mark price всех USDT-свопов
+// двумя запросами: instId=ANY отдаёт фандинг сразу по всем свопам
+func GetAllMarkPrices(client *Client, ctx context.Context) (map[string]exchanges.Funding, error) {
+	var funding []struct {
+		InstID      string `json:"instId"`
+		FundingRate string `json:"fundingRate"`
+		FundingTime string `json:"fundingTime"`
+	}
+	if err := client.get(ctx, "/api/v5/public/funding-rate", url.Values{"instId": {"ANY"}}, &funding); err != nil {
+		return nil, fmt.Errorf("failed to get funding rates: %w", err)
+	}
+	var marks []struct {
+		InstID string `json:"instId"`
+		MarkPx string `json:"markPx"`
+	}
+	if err := client.get(ctx, "/api/v5/public/mark-price", url.Values{"instType": {"SWAP"}}, &marks); err != nil {
+		return nil, fmt.Errorf("failed to get mark prices: %w", err)
+	}
+	markPrices := make(map[string]float64, len(marks))
+	for _, m := range marks {
+		if px, err := strconv.ParseFloat(m.MarkPx, 64); err == nil {
+			markPrices[m.InstID] = px
+		}
+	}
+
+	result := make(map[string]exchanges.Funding, len(funding))
+	for _, f := range funding {
+		if !strings.HasSuffix(f.InstID, "-USDT-SWAP") {
+			continue
+		}
+		rate, err := strconv.ParseFloat(f.FundingRate, 64)
+		if err != nil {
+			continue
+		}
+		next, _ := strconv.ParseInt(f.FundingTime, 10, 64)
+		result[f.InstID] = exchanges.Funding{
+			Symbol:          f.InstID,
+			Rate:            rate,
+			MarkPrice:       markPrices[f.InstID],
+			NextFundingTime: time.UnixMilli(next),
+		}
+	}
+	return result, nil
+}
+
+func GetFundingRate(client *Client, ctx context.Context, symbol string) (exchanges.Funding, error) {
+	var funding []struct {
+		FundingRate string `json:"fundingRate"`
+		FundingTime string `json:"fundingTime"`
+	}
+	if err := client.get(ctx, "/api/v5/public/funding-rate", url.Values{"instId": {symbol}}, &funding); err != nil {
+		return exchanges.Funding{}, fmt.Errorf("failed to get funding rate for %s: %w", symbol, err)
+	}
+	if len(funding) == 0 {
+		return exchanges.Funding{}, fmt.Errorf("no funding rate for %s", symbol)
+	}
+	rate, err := strconv.ParseFloat(funding[0].FundingRate, 64)
+	if err != nil {
+		return exchanges.Funding{}, fmt.Errorf("failed to parse funding rate for %s: %w", symbol, err)
+	}
+	next, _ := strconv.ParseInt(funding[0].FundingTime, 10, 64)
+
+	var mark []struct {
+		MarkPx string `json:"markPx"`
+	}
+	params := url.Values{"instType": {"SWAP"}, "instId": {symbol}}
+	if err := client.get(ctx, "/api/v5/public/mark-price", params, &mark); err != nil {
+		return exchanges.Funding{}, fmt.Errorf("failed to get mark price for %s: %w", symbol, err)
+	}
+	if len(mark) == 0 {
+		return exchanges.Funding{}, fmt.Errorf("no mark price for %s", symbol)
+	}
+	markPrice, err := strconv.ParseFloat(mark[0].MarkPx, 64)
+	if err != nil {
+		return exchanges.Funding{}, fmt.Errorf("failed to parse mark price for %s: %w", symbol, err)
+	}
+
+	return exchanges.Funding{
+		Symbol:          symbol,
+		Rate:            rate,
+		MarkPrice:       markPrice,
+		NextFundingTime: time.UnixMilli(next),
+	}, nil
+}
@@ -0,0 +1,71 @@
+package okx
+
+import (
+	"context"
+
+	"1333/internal/exchanges"
+)
+
+// Provider реализует exchanges.MarketDataProvider для USDT-маржинальных свопов OKX.
+// Символы остаются в нативном виде ("BTC-USDT-SWAP"), OI — в монетах базового актива.
+// OI, фандинг и тикеры хаб берёт пачкой по всем свопам, посимвольно запрашиваются только свечи.
+type Provider struct {
+	client *Client
+}
+
+var (
+	_ exchanges.MarketDataProvider = (*Provider)(nil)
+	_ exchanges.UsageReporter      = (*Provider)(nil)
+	_ exchanges.BulkProvider       = (*Provider)(nil)
+	_ exchanges.InstrumentProvider = (*Provider)(nil)
+)
+
+func NewProvider(client *Client) *Provider {
+	return &Provider{client: client}
+}
+
+func (p *Provider) Name() string {
+	return "OKX"
+}
+
+func (p *Provider) Symbols(ctx context.Context) ([]string, error) {
+	return GetUSDTSwapSymbols(p.client, ctx)
+}
+
+func (p *Provider) Instruments(ctx context.Context) ([]exchanges.Instrument, error) {
+	return GetUSDTSwapInstruments(p.client, ctx)
+}
+
+func (p *Provider) Klines(ctx context.Context, symbol, interval string, limit int) ([]exchanges.Kline, error) {
+	return GetKlines(p.client, ctx, symbol, interval, limit)
+}
+
+func (p *Provider) OpenInterest(ctx context.Context, symbol string) (float64, error) {
+	return GetOpenInterest(p.client, ctx, symbol)
+}
+
+func (p *Provider) LastPrice(ctx context.Context, symbol string) (float64, error) {
+	return GetCurrentPrice(p.client, ctx, symbol)
+}
+
+// FundingRate стоит двух запросов: ставка и mark price у OKX в разных эндпоинтах
+func (p *Provider) FundingRate(ctx context.Context, symbol string) (exchanges.Funding, error) {
+	return GetFundingRate(p.client, ctx, symbol)
+}
+
+func (p *Provider) Tickers(ctx context.Context) (map[string]exchanges.Ticker, error) {
+	return GetTickers(p.client, ctx)
+}
+
+func (p *Provider) MarkPrices(ctx context.Context) (map[string]exchanges.Funding, error) {
+	return GetAllMarkPrices(p.client, ctx)
+}
+
+func (p *Provider) OpenInterests(ctx context.Context, symbols []string) map[string]float64 {
+	return GetAllOpenInterests(p.client, ctx, symbols)
+}
+
+// Usage — доля лимита самого загруженного эндпоинта OKX; лимиты у OKX поэндпоинтные
+func (p *Provider) Usage() float64 {
+	return Usage()
+}
@@ -0,0 +1,131 @@
+package exchanges
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// Оставляем запас под запросы, которых лимитер не видит (другие процессы на том же IP)
+	rateLimitHeadroom = 0.9
+
+	minRateLimitBackoff = time.Second
+	maxRateLimitBackoff = 5 * time.Minute
+)
+
+// RateLimiter ограничивает число запросов за окно period. Нужен площадкам, которые
+// не сообщают расход лимита в заголовках ответа; у Binance свой лимитер по весу.
+type RateLimiter struct {
+	name   string
+	limit  int
+	period time.Duration
+
+	mu           sync.Mutex
+	window       time.Time // начало текущего окна
+	used         int
+	blockedUntil time.Time
+	backoff      time.Duration
+}
+
+func NewRateLimiter(name string, limit int, period time.Duration) *RateLimiter {
+	return &RateLimiter{name: name, limit: limit, period: period}
+}
+
+// Wait резервирует один запрос, при необходимости дожидаясь следующего окна
+// или окончания паузы после 429. Возвращает ошибку только при отмене ctx.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.rollWindow(now)
+
+		var wait time.Duration
+		switch {
+		case now.Before(l.blockedUntil):
+			wait = l.blockedUntil.Sub(now)
+		case float64(l.used+1) > float64(l.limit)*rateLimitHeadroom:
+			wait = l.window.Add(l.period).Sub(now)
+		default:
+			l.used++
+			l.mu.Unlock()
+			return nil
+		}
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// UsageRatio — доля израсходованного бюджета текущего окна, 1 во время паузы после 429
+func (l *RateLimiter) UsageRatio() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.rollWindow(now)
+	if now.Before(l.blockedUntil) {
+		return 1
+	}
+	return float64(l.used) / float64(l.limit)
+}
+
+func (l *RateLimiter) rollWindow(now time.Time) {
+	if w := now.Truncate(l.period); w.After(l.window) {
+		l.window = w
+		l.used = 0
+	}
+}
+
+// observe выдерживает паузу после 429: по Retry-After, иначе с экспоненциальным ростом
+func (l *RateLimiter) observe(res *http.Response) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if res.StatusCode != http.StatusTooManyRequests {
+		l.backoff = 0
+		return
+	}
+	pause := time.Duration(0)
+	if sec, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && sec > 0 {
+		pause = time.Duration(sec) * time.Second
+	} else {
+		l.backoff = min(max(l.backoff*2, minRateLimitBackoff), maxRateLimitBackoff)
+		pause = l.backoff
+	}
+	l.blockedUntil = time.Now().Add(pause)
+	log.Printf("[%s] Превышен лимит запросов, пауза %s", l.name, pause)
+}
+
+// rateLimitedTransport пропускает каждый запрос через лимитер, выбранный по пути
+type rateLimitedTransport struct {
+	base    http.RoundTripper
+	limiter func(path string) *RateLimiter
+}
+
+// NewRateLimitedClient возвращает HTTP-клиент, который перед каждым запросом ждёт
+// лимитер, выбранный limiter по пути запроса
+func NewRateLimitedClient(timeout time.Duration, limiter func(path string) *RateLimiter) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &rateLimitedTransport{base: http.DefaultTransport, limiter: limiter},
+	}
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	l := t.limiter(req.URL.Path)
+	if err := l.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	l.observe(res)
+	return res, nil
+}
@@ -0,0 +1,12 @@
+package exchanges
+
+import "strings"
+
+// CanonicalSymbol приводит тикер площадки к единому виду BASEQUOTE:
+// "BTC-USDT-SWAP" (OKX) и "BTCUSDT" (Binance, Bybit) дают "BTCUSDT".
+// По нему сопоставляются инструменты разных бирж, списки пользователя и история.
+func CanonicalSymbol(native string) string {
+	s := strings.ToUpper(strings.TrimSpace(native))
+	s = strings.TrimSuffix(s, "-SWAP")
+	return strings.ReplaceAll(s, "-", "")
+}
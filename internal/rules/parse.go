@@ -0,0 +1,265 @@
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Пределы на размер правила, чтобы одно сообщение не превратилось в сотню запросов
+const (
+	maxExprLength = 500
+	maxConditions = 10
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // позиция в символах от начала выражения, для сообщений об ошибках
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "конец выражения"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+func tokenize(src string) ([]token, error) {
+	runes := []rune(src)
+	var tokens []token
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '(':
+			tokens = append(tokens, token{tokLParen, "(", start})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokRParen, ")", start})
+			i++
+		case r == '>' || r == '<':
+			i++
+			if i < len(runes) && runes[i] == '=' {
+				i++
+			}
+			tokens = append(tokens, token{tokOp, string(runes[start:i]), start})
+		case r == '&' || r == '|':
+			// && и || — синонимы and и or
+			if i+1 >= len(runes) || runes[i+1] != r {
+				return nil, fmt.Errorf("позиция %d: неожиданный символ %q", start+1, r)
+			}
+			i += 2
+			word := "and"
+			if r == '|' {
+				word = "or"
+			}
+			tokens = append(tokens, token{tokIdent, word, start})
+		case unicode.IsDigit(r) || ((r == '-' || r == '+') && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || unicode.IsLetter(runes[i]) || runes[i] == '.' || runes[i] == ',' || runes[i] == '%') {
+				i++
+			}
+			tokens = append(tokens, token{tokNumber, string(runes[start:i]), start})
+		case unicode.IsLetter(r) || r == '_':
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokIdent, strings.ToLower(string(runes[start:i])), start})
+		default:
+			return nil, fmt.Errorf("позиция %d: неожиданный символ %q", start+1, r)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(runes)}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	conds  int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return fmt.Errorf("позиция %d: %s", t.pos+1, fmt.Sprintf(format, args...))
+}
+
+// Parse разбирает выражение вида
+//
+//	price.change(5m) > 3% and (oi.change(15m) > 5% or volume.spike(5m) >= 3x)
+//
+// AND связывает сильнее OR, как в большинстве языков. Результат уже проверен Validate.
+func Parse(src string) (Node, error) {
+	src = strings.TrimSpace(src)
+	if src == "" {
+		return Node{}, fmt.Errorf("пустое выражение")
+	}
+	if len([]rune(src)) > maxExprLength {
+		return Node{}, fmt.Errorf("выражение длиннее %d символов", maxExprLength)
+	}
+	tokens, err := tokenize(src)
+	if err != nil {
+		return Node{}, err
+	}
+
+	p := &parser{tokens: tokens}
+	n, err := p.parseOr()
+	if err != nil {
+		return Node{}, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return Node{}, p.errorf(t, "ожидалось and, or или конец выражения, а не %s", t)
+	}
+	if err := n.Validate(); err != nil {
+		return Node{}, err
+	}
+	return n, nil
+}
+
+func (p *parser) parseOr() (Node, error) {
+	return p.parseChain("or", p.parseAnd, Any)
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	return p.parseChain("and", p.parseTerm, All)
+}
+
+// parseChain разбирает операнды, разделённые словом op, и объединяет их через join
+func (p *parser) parseChain(op string, operand func() (Node, error), join func(...Node) Node) (Node, error) {
+	first, err := operand()
+	if err != nil {
+		return Node{}, err
+	}
+	nodes := []Node{first}
+	for t := p.peek(); t.kind == tokIdent && t.text == op; t = p.peek() {
+		p.next()
+		n, err := operand()
+		if err != nil {
+			return Node{}, err
+		}
+		nodes = append(nodes, n)
+	}
+	if len(nodes) == 1 {
+		return first, nil
+	}
+	return join(nodes...), nil
+}
+
+func (p *parser) parseTerm() (Node, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokLParen:
+		p.next()
+		n, err := p.parseOr()
+		if err != nil {
+			return Node{}, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return Node{}, p.errorf(closing, "ожидалась ), а не %s", closing)
+		}
+		return n, nil
+	case tokIdent:
+		c, err := p.parseCondition()
+		if err != nil {
+			return Node{}, err
+		}
+		return Cond(c), nil
+	}
+	return Node{}, p.errorf(t, "ожидалась метрика, а не %s", t)
+}
+
+func (p *parser) parseCondition() (Condition, error) {
+	p.conds++
+	if p.conds > maxConditions {
+		return Condition{}, fmt.Errorf("в правиле может быть не больше %d условий", maxConditions)
+	}
+
+	name := p.next()
+	c := Condition{Metric: Metric(name.text)}
+	if _, ok := metrics[c.Metric]; !ok {
+		return Condition{}, p.errorf(name, "неизвестная метрика %s, доступны: %s", name, metricNames())
+	}
+
+	if p.peek().kind == tokLParen {
+		p.next()
+		w := p.next()
+		if w.kind != tokNumber {
+			return Condition{}, p.errorf(w, "ожидалось окно вроде 5m или 1h, а не %s", w)
+		}
+		c.Window = strings.ToLower(w.text)
+		if closing := p.next(); closing.kind != tokRParen {
+			return Condition{}, p.errorf(closing, "ожидалась ), а не %s", closing)
+		}
+	}
+
+	op := p.next()
+	if op.kind != tokOp {
+		return Condition{}, p.errorf(op, "после %s ожидалось сравнение >, >=, < или <=, а не %s", c.Metric, op)
+	}
+	c.Op = Comparator(op.text)
+
+	val := p.next()
+	if val.kind != tokNumber {
+		return Condition{}, p.errorf(val, "ожидалось число, а не %s", val)
+	}
+	v, err := parseValue(val.text)
+	if err != nil {
+		return Condition{}, p.errorf(val, "%v", err)
+	}
+	c.Value = v
+	return c, nil
+}
+
+// parseValue разбирает число с необязательным суффиксом: % и x ничего не меняют,
+// k и m умножают на тысячу и миллион. Запятая принимается как десятичный разделитель.
+func parseValue(s string) (float64, error) {
+	s = strings.ReplaceAll(strings.ToLower(s), ",", ".")
+	mult := 1.0
+	switch {
+	case strings.HasSuffix(s, "%"), strings.HasSuffix(s, "x"):
+		s = s[:len(s)-1]
+	case strings.HasSuffix(s, "k"):
+		s, mult = s[:len(s)-1], 1e3
+	case strings.HasSuffix(s, "m"):
+		s, mult = s[:len(s)-1], 1e6
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось разобрать число %q", s)
+	}
+	return v * mult, nil
+}
+
+func metricNames() string {
+	names := []string{
+		string(PriceChange), string(OIChange), string(Volume), string(VolumeSpike),
+		string(Funding), string(Liquidations), string(LongShort), string(TakerBuySell),
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,149 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string // выражение в каноничной записи Node.String
+	}{
+		{"одно условие", "price.change(5m) > 3%", "price.change(5m) > 3%"},
+		{"без пробелов", "price.change(5m)>=3%", "price.change(5m) >= 3%"},
+		{"регистр", "PRICE.CHANGE(5M) > 3% AND Funding < 0", "price.change(5m) > 3% and funding < 0%"},
+		{"and сильнее or", "price.change(5m) > 3% or oi.change(15m) > 5% and funding > 0.1%",
+			"price.change(5m) > 3% or (oi.change(15m) > 5% and funding > 0.1%)"},
+		{"and сильнее or слева", "price.change(5m) > 3% and oi.change(15m) > 5% or funding > 0.1%",
+			"(price.change(5m) > 3% and oi.change(15m) > 5%) or funding > 0.1%"},
+		{"скобки", "(price.change(5m) > 3% or oi.change(15m) > 5%) and funding > 0.1%",
+			"(price.change(5m) > 3% or oi.change(15m) > 5%) and funding > 0.1%"},
+		{"лишние скобки", "((price.change(5m) > 3%))", "price.change(5m) > 3%"},
+		{"цепочка and", "price.change(5m) > 1% and volume(5m) > 1 and funding > 0", "price.change(5m) > 1% and volume(5m) > 1 and funding > 0%"},
+		{"синонимы && и ||", "price.change(5m) > 3% && funding > 0 || long_short < 1",
+			"(price.change(5m) > 3% and funding > 0%) or long_short < 1"},
+		{"процент", "oi.change(1h) > 2.5%", "oi.change(1h) > 2.5%"},
+		{"запятая", "oi.change(1h) > 2,5%", "oi.change(1h) > 2.5%"},
+		{"множитель", "volume.spike(5m) >= 3x", "volume.spike(5m) >= 3x"},
+		{"тысячи", "volume(15m) > 500k", "volume(15m) > 500000"},
+		{"миллионы", "liquidations(30m) > 1.5m", "liquidations(30m) > 1500000"},
+		{"окно в днях", "oi.change(1d) > 10%", "oi.change(1d) > 10%"},
+		{"отрицательное", "price.change(5m) < -3%", "price.change(5m) < -3%"},
+		{"отрицательный фандинг", "funding <= -0.01%", "funding <= -0.01%"},
+		{"знак плюс", "price.change(5m) > +3%", "price.change(5m) > 3%"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.src, err)
+			}
+			if got := n.String(); got != tt.want {
+				t.Fatalf("Parse(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseValues(t *testing.T) {
+	tests := []struct {
+		src  string
+		want float64
+	}{
+		{"volume(5m) > 250000", 250000},
+		{"volume(5m) > 250k", 250000},
+		{"volume(5m) > 2.5M", 2.5e6},
+		{"volume.spike(5m) > 2,5x", 2.5},
+		{"price.change(5m) < -1.25%", -1.25},
+		{"funding > 0.05", 0.05},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			n, err := Parse(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := n.Cond.Value; got != tt.want {
+				t.Fatalf("значение %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{"пустое", "   ", "пустое выражение"},
+		{"слишком длинное", "funding > " + strings.Repeat("0", maxExprLength), "длиннее"},
+		{"неизвестная метрика", "price > 1", "неизвестная метрика \"price\""},
+		{"нет сравнения", "price.change(5m) 3%", "ожидалось сравнение"},
+		{"нет числа", "price.change(5m) > abc", "ожидалось число"},
+		{"кривое число", "price.change(5m) > 3q", "не удалось разобрать число"},
+		{"висящий and", "price.change(5m) > 3% and", "позиция 26: ожидалась метрика, а не конец выражения"},
+		{"незакрытая скобка", "(price.change(5m) > 3%", "ожидалась ), а не конец выражения"},
+		{"лишняя скобка", "price.change(5m) > 3%)", "ожидалось and, or или конец выражения"},
+		{"нет связки", "price.change(5m) > 3% funding > 0", "ожидалось and, or"},
+		{"одиночный &", "price.change(5m) > 3% & funding > 0", "позиция 23: неожиданный символ '&'"},
+		{"неизвестный символ", "price.change(5m) = 3%", "неожиданный символ '='"},
+		{"окно не число", "price.change(x) > 3%", "ожидалось окно"},
+		{"окно не таймфрейм", "price.change(3h) > 3%", "таймфреймом свечи"},
+		{"окно у funding", "funding(5m) > 0.1%", "нет окна"},
+		{"нет окна у OI", "oi.change > 5%", "нужно окно"},
+		{"окно OI больше суток", "oi.change(48h) > 5%", "не может быть больше"},
+		{"окно ликвидаций больше часа", "liquidations(2h) > 1m", "не может быть больше"},
+		{"слишком много условий", strings.Repeat("funding > 0 or ", maxConditions) + "funding > 0", "не больше 10 условий"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.src)
+			if err == nil {
+				t.Fatalf("Parse(%q) не вернул ошибку", tt.src)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ошибка %q, ожидалось %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// values — значения метрик для Eval, ключ — запись условия без сравнения
+type values map[string]float64
+
+func (v values) Value(c Condition) (float64, bool) {
+	name := string(c.Metric)
+	if c.Window != "" {
+		name += "(" + c.Window + ")"
+	}
+	val, ok := v[name]
+	return val, ok
+}
+
+func TestEvalPrecedence(t *testing.T) {
+	n, err := Parse("price.change(5m) > 3% or oi.change(15m) > 5% and funding > 0.1%")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		v    values
+		want bool
+	}{
+		{"только цена", values{"price.change(5m)": 4}, true},
+		{"OI без фандинга", values{"oi.change(15m)": 6, "funding": 0.05}, false},
+		{"OI и фандинг", values{"oi.change(15m)": 6, "funding": 0.2}, true},
+		{"нет данных", values{}, false},
+		{"отрицательное изменение", values{"price.change(5m)": -4}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := n.Eval(tt.v); got != tt.want {
+				t.Fatalf("Eval = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+// Package rules описывает составные условия алертов: метрика, сравнение,
+// порог и окно, объединённые через AND/OR. Значения метрик даёт монитор.
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"1333/internal/exchanges"
+)
+
+type Metric string
+
+const (
+	PriceChange  Metric = "price.change" // изменение цены в % за свечу окна
+	OIChange     Metric = "oi.change"    // изменение OI в % за окно
+	Volume       Metric = "volume"       // объём свечи окна в USDT
+	VolumeSpike  Metric = "volume.spike" // объём свечи окна к среднему, во сколько раз
+	Funding      Metric = "funding"      // ставка финансирования в %
+	Liquidations Metric = "liquidations" // ликвидации в USDT за окно
+	LongShort    Metric = "long_short"   // отношение аккаунтов в лонге к шортам
+	TakerBuySell Metric = "taker"        // отношение покупок тейкеров к продажам
+)
+
+// FuturesOnly — метрика есть только у фьючерсов, на споте её не бывает
+func (m Metric) FuturesOnly() bool {
+	switch m {
+	case OIChange, Funding, Liquidations, LongShort, TakerBuySell:
+		return true
+	}
+	return false
+}
+
+type windowKind int
+
+const (
+	noWindow     windowKind = iota
+	klineWindow             // окно — таймфрейм свечи
+	periodWindow            // произвольный период не длиннее maxWindow
+)
+
+type metricSpec struct {
+	window    windowKind
+	maxWindow time.Duration
+}
+
+var metrics = map[Metric]metricSpec{
+	PriceChange:  {window: klineWindow},
+	OIChange:     {window: periodWindow, maxWindow: 24 * time.Hour},
+	Volume:       {window: klineWindow},
+	VolumeSpike:  {window: klineWindow},
+	Funding:      {window: noWindow},
+	Liquidations: {window: periodWindow, maxWindow: time.Hour},
+	LongShort:    {window: noWindow},
+	TakerBuySell: {window: noWindow},
+}
+
+type Comparator string
+
+const (
+	Greater      Comparator = ">"
+	GreaterEqual Comparator = ">="
+	Less         Comparator = "<"
+	LessEqual    Comparator = "<="
+)
+
+func (c Comparator) compare(a, b float64) bool {
+	switch c {
+	case Greater:
+		return a > b
+	case GreaterEqual:
+		return a >= b
+	case Less:
+		return a < b
+	case LessEqual:
+		return a <= b
+	}
+	return false
+}
+
+// Condition — одно сравнение: metric(window) op value
+type Condition struct {
+	Metric Metric     `json:"metric"`
+	Window string     `json:"window,omitempty"` // "5m", "1h", "1d"; пусто у метрик без окна
+	Op     Comparator `json:"op"`
+	Value  float64    `json:"value"`
+}
+
+// Duration возвращает окно условия, 0 если окна нет
+func (c Condition) Duration() time.Duration {
+	d, _ := ParseWindow(c.Window)
+	return d
+}
+
+func (c Condition) String() string {
+	name := string(c.Metric)
+	if c.Window != "" {
+		name += "(" + c.Window + ")"
+	}
+	return fmt.Sprintf("%s %s %s%s", name, c.Op, formatValue(c.Value), c.Metric.unit())
+}
+
+func (m Metric) unit() string {
+	switch m {
+	case PriceChange, OIChange, Funding:
+		return "%"
+	case VolumeSpike:
+		return "x"
+	}
+	return ""
+}
+
+func formatValue(v float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.4f", v), "0"), ".")
+}
+
+func (c Condition) validate() error {
+	spec, ok := metrics[c.Metric]
+	if !ok {
+		return fmt.Errorf("неизвестная метрика %q", c.Metric)
+	}
+	switch c.Op {
+	case Greater, GreaterEqual, Less, LessEqual:
+	default:
+		return fmt.Errorf("неизвестное сравнение %q", c.Op)
+	}
+
+	switch spec.window {
+	case noWindow:
+		if c.Window != "" {
+			return fmt.Errorf("у метрики %s нет окна", c.Metric)
+		}
+	case klineWindow:
+		if !isKlineInterval(c.Window) {
+			return fmt.Errorf("окно %s должно быть таймфреймом свечи: %s", c.Metric, strings.Join(exchanges.Timeframes, ", "))
+		}
+	case periodWindow:
+		d, err := ParseWindow(c.Window)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("у метрики %s нужно окно, например %s(15m)", c.Metric, c.Metric)
+		}
+		if d > spec.maxWindow {
+			return fmt.Errorf("окно %s не может быть больше %s", c.Metric, spec.maxWindow)
+		}
+	}
+	return nil
+}
+
+func isKlineInterval(w string) bool {
+	_, ok := exchanges.TimeframeDuration(w)
+	return ok
+}
+
+// ParseWindow разбирает окно вида 15m, 4h или 1d
+func ParseWindow(w string) (time.Duration, error) {
+	if w == "" {
+		return 0, nil
+	}
+	if n, ok := strings.CutSuffix(w, "d"); ok {
+		d, err := time.ParseDuration(n + "h")
+		return d * 24, err
+	}
+	return time.ParseDuration(w)
+}
+
+// Node — узел выражения: либо условие, либо AND/OR над дочерними узлами
+type Node struct {
+	And  []Node     `json:"and,omitempty"`
+	Or   []Node     `json:"or,omitempty"`
+	Cond *Condition `json:"cond,omitempty"`
+}
+
+func All(nodes ...Node) Node { return Node{And: nodes} }
+func Any(nodes ...Node) Node { return Node{Or: nodes} }
+func Cond(c Condition) Node  { return Node{Cond: &c} }
+
+// Values отдаёт значения метрик по одному символу; ok=false, если данных нет
+type Values interface {
+	Value(c Condition) (float64, bool)
+}
+
+// Eval проверяет выражение. Условие без данных считается невыполненным.
+func (n Node) Eval(v Values) bool {
+	switch {
+	case n.Cond != nil:
+		val, ok := v.Value(*n.Cond)
+		return ok && n.Cond.Op.compare(val, n.Cond.Value)
+	case len(n.And) > 0:
+		for _, child := range n.And {
+			if !child.Eval(v) {
+				return false
+			}
+		}
+		return true
+	case len(n.Or) > 0:
+		for _, child := range n.Or {
+			if child.Eval(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (n Node) children() []Node {
+	if len(n.And) > 0 {
+		return n.And
+	}
+	return n.Or
+}
+
+// Conditions возвращает все условия выражения в порядке записи
+func (n Node) Conditions() []Condition {
+	if n.Cond != nil {
+		return []Condition{*n.Cond}
+	}
+	var res []Condition
+	for _, child := range n.children() {
+		res = append(res, child.Conditions()...)
+	}
+	return res
+}
+
+func (n Node) Validate() error {
+	kinds := 0
+	if n.Cond != nil {
+		kinds++
+	}
+	if len(n.And) > 0 {
+		kinds++
+	}
+	if len(n.Or) > 0 {
+		kinds++
+	}
+	if kinds != 1 {
+		return errors.New("пустое или неоднозначное выражение")
+	}
+	if n.Cond != nil {
+		return n.Cond.validate()
+	}
+	for _, child := range n.children() {
+		if err := child.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n Node) String() string {
+	return n.format(false)
+}
+
+// format печатает выражение; вложенные группы берутся в скобки
+func (n Node) format(nested bool) string {
+	if n.Cond != nil {
+		return n.Cond.String()
+	}
+	children, sep := n.children(), " and "
+	if len(n.Or) > 0 {
+		sep = " or "
+	}
+	parts := make([]string, len(children))
+	for i, child := range children {
+		parts[i] = child.format(true)
+	}
+	s := strings.Join(parts, sep)
+	if nested && len(parts) > 1 {
+		s = "(" + s + ")"
+	}
+	return s
+}
+
+// Rule — сохранённое правило пользователя
+type Rule struct {
+	ID   int  `json:"id"`
+	Expr Node `json:"expr"`
+}
+
+func (r Rule) String() string {
+	return fmt.Sprintf("#%d %s", r.ID, r.Expr)
+}
+
+// NextID возвращает свободный номер для нового правила
+func NextID(rs []Rule) int {
+	id := 0
+	for _, r := range rs {
+		id = max(id, r.ID)
+	}
+	return id + 1
+}
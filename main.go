@@ -9,7 +9,10 @@ import (
 	"syscall"
 
 	"1333/internal/bots"
+	"1333/internal/exchanges"
 	"1333/internal/exchanges/binance"
+	"1333/internal/exchanges/bybit"
+	"1333/internal/exchanges/okx"
 	"1333/persistence"
 
 	"github.com/jackc/pgtype"
@@ -24,6 +27,8 @@ type Config struct {
 	AdditionalUsernames map[string]string `json:"additional_usernames"`
 	BinanceAPIKey       string            `json:"binance_api_key"`
 	BinanceAPISecret    string            `json:"binance_api_secret"`
+	BinanceStreaming    bool              `json:"binance_streaming"`
+	DeepLinkSecret      string            `json:"deep_link_secret"`
 }
 
 func main() {
@@ -42,22 +47,49 @@ func main() {
 		log.Fatal(err)
 	}
 
+	hubs := make(map[string]*persistence.Hub)
+	bn := binance.NewProvider(binance.NewClient(cfg.BinanceAPIKey, cfg.BinanceAPISecret))
+	if hub := newHub(ctx, bn); hub != nil {
+		if cfg.BinanceStreaming {
+			hub.EnableStreaming(bn)
+		}
+		hubs["binance"] = hub
+	}
+	if hub := newHub(ctx, bybit.NewProvider(bybit.NewClient())); hub != nil {
+		hubs["bybit"] = hub
+	}
+	if hub := newHub(ctx, okx.NewProvider(okx.NewClient())); hub != nil {
+		hubs["okx"] = hub
+	}
+	if hub := newHub(ctx, binance.NewSpotProvider(binance.NewSpotClient(cfg.BinanceAPIKey, cfg.BinanceAPISecret))); hub != nil {
+		hubs["binance_spot"] = hub
+	}
+	if len(hubs) == 0 {
+		log.Fatal("Нет доступных бирж для мониторинга")
+	}
+	for _, hub := range hubs {
+		go hub.Run(ctx)
+	}
+
 	store := persistence.NewUserStore("data/user_store.json")
 	if err := store.Load(); err != nil {
 		log.Printf("load error: %v", err)
 	}
 	mgr.Bots["main"].Users = store.All()
+	monitors := persistence.NewMonitorRegistry(ctx, hubs, mgr.SendToBot)
+	mgr.Bots["main"].StatusFn = monitors.Status
+	mgr.Bots["main"].DeepLinkSecret = []byte(cfg.DeepLinkSecret)
 	mgr.Bots["main"].OnSettingsFn = func(userID int64, s bots.UserSettings) {
 		store.Set(userID, s)
 		if err := store.Save(); err != nil {
 			log.Printf("Error saving user settings for %d: %v", userID, err)
 		}
-		go startUserMonitoring(ctx, userID, &s, cfg.BinanceAPIKey, cfg.BinanceAPISecret, mgr)
+		monitors.Apply(userID, s)
 	}
 
 	for uid, us := range mgr.Bots["main"].Users {
-		if (us.TimeFrame != "" && us.ChangeThreshold > 0 && us.TargetBot != "") || (us.MonitorOI && us.OIThreshold > 0) {
-			go startUserMonitoring(ctx, uid, us, cfg.BinanceAPIKey, cfg.BinanceAPISecret, mgr)
+		if us.HasMonitoring() {
+			monitors.Apply(uid, *us)
 		}
 	}
 
@@ -74,16 +106,13 @@ func main() {
 	<-sigC
 }
 
-func startUserMonitoring(ctx context.Context, userID int64, s *bots.UserSettings, apiKey, apiSecret string, mgr *bots.BotManager) {
-	c := binance.NewClient(apiKey, apiSecret)
-	symbols, err := binance.GetUSDMFuturesSymbols(c, ctx)
+func newHub(ctx context.Context, md exchanges.MarketDataProvider) *persistence.Hub {
+	symbols, err := md.Symbols(ctx)
 	if err != nil || len(symbols) == 0 {
-		log.Printf("Пользователь %d: нет доступных символов для мониторинга", userID)
-		return
+		log.Printf("%s: нет доступных символов для мониторинга: %v", md.Name(), err)
+		return nil
 	}
-	persistence.StartMonitoring(ctx, userID, *s, symbols, func(u int64, text string) {
-		mgr.SendToBot(s.TargetBot, u, text)
-	}, c)
+	return persistence.NewHub(md, symbols)
 }
 
 func loadConfig(path string) (*Config, error) {
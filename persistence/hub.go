@@ -0,0 +1,792 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"1333/internal/bots"
+	"1333/internal/exchanges"
+	"1333/internal/rules"
+)
+
+const (
+	hubTickInterval     = time.Minute
+	streamFlushInterval = 2 * time.Second
+	subscriptionBuffer  = 16
+	hubWorkers          = 10
+
+	// База для всплеска объёма — средний объём стольких предыдущих свечей
+	volumeBaselineCandles = 20
+	// Сколько последних свечей хранится по каждому символу в режиме стриминга
+	liveKlinesDepth = volumeBaselineCandles + 1
+
+	// Статистика позиционирования у Binance считается пятиминутками, чаще обновлять незачем
+	positioningPeriod  = "5m"
+	positioningStep    = 5 * time.Minute
+	positioningRefresh = 5 * time.Minute
+
+	// История OI с биржи для старта хаба: шаг и предел openInterestHist по числу точек
+	oiSeedPeriod    = "5m"
+	oiSeedStep      = 5 * time.Minute
+	oiSeedMaxPoints = 500
+
+	// При таком расходе лимита запросов хаб перестаёт запрашивать OI до следующего тика
+	degradedUsage = 0.75
+)
+
+// SymbolSnapshot — рыночные данные одного символа за тик хаба
+type SymbolSnapshot struct {
+	Symbol     string // тикер в формате биржи, для запросов к ней
+	Instrument string // каноничный тикер, общий для всех бирж
+	Price      float64
+	OI         float64
+	Funding    *exchanges.Funding
+	// Ликвидации за окна, запрошенные подписчиками
+	Liquidations map[time.Duration]exchanges.LiquidationStats
+	// История позиционирования с шагом positioningPeriod, от старых точек к новым
+	Positioning []exchanges.Positioning
+	// Для фильтров ликвидности; нулевые, если площадка эти данные не отдаёт
+	QuoteVolume24h float64
+	ListedAt       time.Time
+	Klines         map[string][]exchanges.Kline // ключ — таймфрейм
+}
+
+// MarketSnapshot — всё, что хаб получил от биржи за один тик
+type MarketSnapshot struct {
+	Time     time.Time
+	Exchange string
+	Symbols  map[string]*SymbolSnapshot
+	// Снапшот REST-тика по всем символам. Стриминговые снапшоты несут только символы
+	// со свежими свечами, а OI, фандинг, ликвидации и позиционирование — из последнего тика.
+	Full bool
+	// Листинги и делистинги, найденные при обновлении списка символов; у такого снапшота нет Symbols
+	Listings []ListingEvent
+}
+
+type subscription struct {
+	userID   int64
+	settings bots.UserSettings
+	ch       chan *MarketSnapshot
+}
+
+// Hub раз в тик запрашивает данные по каждому символу и таймфрейму ровно
+// один раз и раздаёт снапшот всем подписанным пользователям.
+// В режиме стриминга свечи и цены приходят по WebSocket, а REST-тик
+// остаётся для OI и прочих метрик без стрима.
+type Hub struct {
+	md exchanges.MarketDataProvider
+
+	// Список символов меняется при обновлении вселенной, см. refreshSymbols
+	symbolsMu        sync.RWMutex
+	symbols          []string
+	symbolsRefreshed time.Time
+
+	mu   sync.Mutex
+	subs map[*subscription]struct{}
+
+	stream exchanges.StreamingProvider
+
+	// Площадка ответила ErrNotSupported (спот) — больше эти метрики не запрашиваем
+	noOI      atomic.Bool
+	noFunding atomic.Bool
+
+	positioningMu sync.Mutex
+	positioning   map[string][]exchanges.Positioning
+	positioningAt time.Time
+
+	// Даты листинга, нужны только фильтру по возрасту тикера
+	listedAt map[string]time.Time
+
+	// История OI общая для всех подписчиков, ключ — инструмент
+	oiMu      sync.RWMutex
+	oiHistory map[string]*OIHistory
+
+	seedMu      sync.Mutex
+	oiSeedDepth time.Duration // глубина истории OI, уже загруженной с биржи или загружаемой сейчас
+
+	liveMu     sync.Mutex
+	live       map[string]map[string][]exchanges.Kline // таймфрейм -> символ -> последние свечи
+	dirty      map[string]map[string]bool
+	marks      map[string]float64
+	rest       map[string]*SymbolSnapshot    // символы последнего REST-тика, для стриминговых снапшотов
+	streamStop map[string]context.CancelFunc // таймфрейм -> остановка стрима свечей
+	liqStarted bool
+}
+
+func NewHub(md exchanges.MarketDataProvider, symbols []string) *Hub {
+	return &Hub{
+		md:               md,
+		symbols:          symbols,
+		symbolsRefreshed: time.Now(),
+		subs:             make(map[*subscription]struct{}),
+		live:             make(map[string]map[string][]exchanges.Kline),
+		dirty:            make(map[string]map[string]bool),
+		marks:            make(map[string]float64),
+		streamStop:       make(map[string]context.CancelFunc),
+		positioning:      make(map[string][]exchanges.Positioning),
+		oiHistory:        make(map[string]*OIHistory),
+	}
+}
+
+// EnableStreaming переключает хаб на WebSocket-данные; вызывается до Run
+func (h *Hub) EnableStreaming(sp exchanges.StreamingProvider) {
+	h.stream = sp
+}
+
+// Subscribe регистрирует пользователя и возвращает канал снапшотов и функцию отписки
+func (h *Hub) Subscribe(userID int64, s bots.UserSettings) (<-chan *MarketSnapshot, func()) {
+	sub := &subscription{
+		userID:   userID,
+		settings: s,
+		ch:       make(chan *MarketSnapshot, subscriptionBuffer),
+	}
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub.ch, func() {
+		h.mu.Lock()
+		delete(h.subs, sub)
+		h.mu.Unlock()
+	}
+}
+
+func (h *Hub) Run(ctx context.Context) {
+	ticker := time.NewTicker(hubTickInterval)
+	defer ticker.Stop()
+
+	log.Printf("[Hub %s] Старт опроса для %d символов", h.md.Name(), len(h.currentSymbols()))
+	if h.stream != nil {
+		go h.stream.StreamMarkPrices(ctx, h.onMarkPrice)
+		go h.flushLoop(ctx)
+	}
+	for {
+		if time.Since(h.symbolsRefreshed) >= symbolsRefreshInterval {
+			h.refreshSymbols(ctx)
+		}
+		h.tick(ctx)
+		select {
+		case <-ctx.Done():
+			log.Printf("[Hub %s] Завершение опроса", h.md.Name())
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// klineTimeframes возвращает таймфреймы, свечи которых нужны пользователю, и сколько
+// последних свечей нужно по каждому: две для изменения цены, больше для базы объёма
+func klineTimeframes(s bots.UserSettings) map[string]int {
+	tfs := make(map[string]int)
+	if s.TimeFrame != "" && (s.ChangeThreshold > 0 || s.VolumeThreshold > 0) {
+		tfs[s.TimeFrame] = 2
+	}
+	if s.VolumeTimeFrame != "" && s.VolumeMultiplier > 0 {
+		tfs[s.VolumeTimeFrame] = volumeBaselineCandles + 1
+	}
+	for _, r := range s.Rules {
+		for _, c := range r.Expr.Conditions() {
+			switch c.Metric {
+			case rules.PriceChange, rules.Volume:
+				tfs[c.Window] = max(tfs[c.Window], 2)
+			case rules.VolumeSpike:
+				tfs[c.Window] = volumeBaselineCandles + 1
+			}
+		}
+	}
+	return tfs
+}
+
+// hubNeeds — что нужно запросить у биржи, чтобы обслужить всех подписчиков
+type hubNeeds struct {
+	timeframes map[string]int // таймфрейм -> сколько последних свечей запросить
+	oi         bool
+	// Самое длинное окно OI среди подписчиков, под него хранится история
+	oiDepth    time.Duration
+	funding    bool
+	liqWindows []time.Duration
+	// Самое длинное окно по позиционированию, 0 если никому не нужно
+	positioningWindow time.Duration
+	// Кто-то из подписчиков фильтрует тикеры по ликвидности или возрасту
+	universe bool
+	listing  bool
+}
+
+// requirements собирает таймфреймы и метрики, которые нужны хотя бы одному подписчику
+func (h *Hub) requirements() (needs hubNeeds, subs []*subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	needs.timeframes = make(map[string]int)
+	seenLiq := make(map[time.Duration]bool)
+	for sub := range h.subs {
+		subs = append(subs, sub)
+		s := sub.settings
+		for tf, depth := range klineTimeframes(s) {
+			needs.timeframes[tf] = max(needs.timeframes[tf], depth)
+		}
+		if s.MonitorOI && s.OIThreshold > 0 {
+			needs.oi = true
+		}
+		needs.oiDepth = max(needs.oiDepth, oiDepth(s))
+		if s.FundingThreshold > 0 || s.FundingFlipAlert {
+			needs.funding = true
+		}
+		needs.positioningWindow = max(needs.positioningWindow, positioningWindow(s))
+		if s.HasUniverseFilters() {
+			needs.universe = true
+			needs.oi = needs.oi || s.MinOINotional > 0
+			needs.listing = needs.listing || s.MinListingAge > 0
+		}
+		liqWindows := []time.Duration{liquidationWindow(s)}
+		for _, r := range s.Rules {
+			for _, c := range r.Expr.Conditions() {
+				switch c.Metric {
+				case rules.OIChange:
+					needs.oi = true
+				case rules.Funding:
+					needs.funding = true
+				case rules.Liquidations:
+					liqWindows = append(liqWindows, c.Duration())
+				case rules.LongShort, rules.TakerBuySell:
+					needs.positioningWindow = max(needs.positioningWindow, positioningStep)
+				}
+			}
+		}
+		for _, w := range liqWindows {
+			if w > 0 && !seenLiq[w] {
+				seenLiq[w] = true
+				needs.liqWindows = append(needs.liqWindows, w)
+			}
+		}
+	}
+	return needs, subs
+}
+
+// liquidationWindow — окно алерта по ликвидациям, 0 если алерт не настроен
+func liquidationWindow(s bots.UserSettings) time.Duration {
+	if s.LiqThreshold <= 0 || s.LiqWindow <= 0 {
+		return 0
+	}
+	return time.Duration(s.LiqWindow) * time.Minute
+}
+
+// positioningWindow — за какой период пользователю нужна история позиционирования.
+// Для пересечения порога достаточно двух последних точек.
+func positioningWindow(s bots.UserSettings) time.Duration {
+	if s.RatioMetric == "" || (s.RatioCross <= 0 && s.RatioShift <= 0) {
+		return 0
+	}
+	return max(time.Duration(s.RatioWindow)*time.Minute, positioningStep)
+}
+
+func (h *Hub) tick(ctx context.Context) {
+	needs, subs := h.requirements()
+	if len(subs) == 0 {
+		return
+	}
+	timeframes := needs.timeframes
+	needOI := needs.oi && !h.noOI.Load()
+	needFunding := needs.funding && !h.noFunding.Load()
+
+	liqSource, hasLiq := h.md.(exchanges.LiquidationSource)
+	if hasLiq && len(needs.liqWindows) > 0 {
+		h.ensureLiquidationStream(ctx, liqSource)
+	}
+
+	if h.stream != nil {
+		// Свечи по этим таймфреймам приходят из стрима, REST нужен только для OI
+		for tf := range timeframes {
+			h.ensureKlineStream(ctx, tf)
+		}
+		timeframes = nil
+		if !needOI && !needFunding && len(needs.liqWindows) == 0 && needs.positioningWindow == 0 && !needs.universe {
+			return
+		}
+	}
+
+	if needs.listing {
+		h.loadListingDates(ctx)
+	}
+
+	symbols := h.currentSymbols()
+	snap := &MarketSnapshot{
+		Time:     time.Now(),
+		Exchange: h.md.Name(),
+		Symbols:  make(map[string]*SymbolSnapshot, len(symbols)),
+		Full:     true,
+	}
+	for _, sym := range symbols {
+		snap.Symbols[sym] = &SymbolSnapshot{
+			Symbol:     sym,
+			Instrument: exchanges.CanonicalSymbol(sym),
+			Klines:     make(map[string][]exchanges.Kline, len(timeframes)),
+			ListedAt:   h.listedAt[sym],
+		}
+	}
+
+	bulk, isBulk := h.md.(exchanges.BulkProvider)
+	// Суточная статистика нужна фильтру ликвидности; у bulk-площадок она ещё и источник цен
+	if tp, ok := h.md.(exchanges.TickerProvider); ok && (isBulk || needs.universe) {
+		tickers, err := tp.Tickers(ctx)
+		if err != nil {
+			log.Printf("[Hub %s] Ошибка получения тикеров: %v", h.md.Name(), err)
+		}
+		for sym, t := range tickers {
+			if ss, ok := snap.Symbols[sym]; ok {
+				ss.Price = t.LastPrice
+				ss.QuoteVolume24h = t.QuoteVolume
+			}
+		}
+	}
+	if needFunding && isBulk {
+		funding, err := bulk.MarkPrices(ctx)
+		if err != nil {
+			log.Printf("[Hub %s] Ошибка получения ставок финансирования: %v", h.md.Name(), err)
+		}
+		for sym, f := range funding {
+			if ss, ok := snap.Symbols[sym]; ok {
+				ss.Funding = &f
+			}
+		}
+	}
+	if needOI && h.usage() >= degradedUsage {
+		log.Printf("[Hub %s] Лимит запросов почти исчерпан, OI в этом тике пропускается", h.md.Name())
+		needOI = false
+	}
+	if needOI && isBulk {
+		for sym, oi := range bulk.OpenInterests(ctx, symbols) {
+			if ss, ok := snap.Symbols[sym]; ok {
+				ss.OI = oi
+			}
+		}
+	}
+
+	if hasLiq && len(needs.liqWindows) > 0 {
+		for _, ss := range snap.Symbols {
+			ss.Liquidations = make(map[time.Duration]exchanges.LiquidationStats, len(needs.liqWindows))
+			for _, w := range needs.liqWindows {
+				ss.Liquidations[w] = liqSource.Liquidations(ss.Symbol, w)
+			}
+		}
+	}
+
+	posSource, hasPos := h.md.(exchanges.PositioningProvider)
+	hasPos = hasPos && needs.positioningWindow > 0
+	h.positioningMu.Lock()
+	refreshPos := hasPos && time.Since(h.positioningAt) >= positioningRefresh && h.statsUsage() < degradedUsage
+	h.positioningMu.Unlock()
+	posLimit := int(needs.positioningWindow/positioningStep) + 1
+
+	h.forEachSymbol(ctx, snap, func(ss *SymbolSnapshot) {
+		if refreshPos {
+			series, err := posSource.Positioning(ctx, ss.Symbol, positioningPeriod, posLimit)
+			if err != nil {
+				log.Printf("[Hub %s] Ошибка получения позиционирования для %s: %v", h.md.Name(), ss.Symbol, err)
+			} else {
+				h.positioningMu.Lock()
+				h.positioning[ss.Symbol] = series
+				h.positioningMu.Unlock()
+			}
+		}
+		if hasPos {
+			h.positioningMu.Lock()
+			ss.Positioning = h.positioning[ss.Symbol]
+			h.positioningMu.Unlock()
+		}
+
+		for tf, depth := range timeframes {
+			klines, err := h.md.Klines(ctx, ss.Symbol, tf, depth)
+			if err != nil {
+				log.Printf("[Hub %s] Ошибка получения свечей %s для %s: %v", h.md.Name(), tf, ss.Symbol, err)
+				continue
+			}
+			ss.Klines[tf] = klines
+			if ss.Price == 0 && len(klines) > 0 {
+				ss.Price = klines[len(klines)-1].Close
+			}
+		}
+		if price, ok := h.markPrice(ss.Symbol); ok {
+			ss.Price = price
+		}
+		if needFunding && !isBulk {
+			f, err := h.md.FundingRate(ctx, ss.Symbol)
+			if errors.Is(err, exchanges.ErrNotSupported) {
+				if h.noFunding.CompareAndSwap(false, true) {
+					log.Printf("[Hub %s] Площадка не отдаёт фандинг, больше не запрашиваем", h.md.Name())
+				}
+			} else if err != nil {
+				log.Printf("[Hub %s] Ошибка получения фандинга для %s: %v", h.md.Name(), ss.Symbol, err)
+			} else {
+				ss.Funding = &f
+			}
+		}
+		if needOI && !isBulk {
+			oi, err := h.md.OpenInterest(ctx, ss.Symbol)
+			if errors.Is(err, exchanges.ErrNotSupported) {
+				if h.noOI.CompareAndSwap(false, true) {
+					log.Printf("[Hub %s] Площадка не отдаёт OI, больше не запрашиваем", h.md.Name())
+				}
+			} else if err != nil {
+				log.Printf("[Hub %s] Ошибка получения OI для %s: %v", h.md.Name(), ss.Symbol, err)
+			} else {
+				ss.OI = oi
+			}
+		}
+	})
+	if ctx.Err() != nil {
+		return
+	}
+	if refreshPos {
+		h.positioningMu.Lock()
+		h.positioningAt = snap.Time
+		h.positioningMu.Unlock()
+	}
+	if needs.oiDepth > 0 {
+		h.recordOI(snap, oiHistoryCapacity(needs.oiDepth))
+		h.ensureOISeed(ctx, needs.oiDepth)
+	}
+	if h.stream != nil {
+		h.liveMu.Lock()
+		h.rest = snap.Symbols
+		h.liveMu.Unlock()
+	}
+
+	h.publish(subs, snap)
+}
+
+// loadListingDates один раз загружает даты листинга, если площадка их отдаёт.
+// Вызывается только из tick, поэтому без блокировки.
+func (h *Hub) loadListingDates(ctx context.Context) {
+	src, ok := h.md.(exchanges.InstrumentProvider)
+	if !ok || h.listedAt != nil {
+		return
+	}
+	instruments, err := src.Instruments(ctx)
+	if err != nil {
+		log.Printf("[Hub %s] Ошибка получения дат листинга: %v", h.md.Name(), err)
+		return
+	}
+	h.listedAt = make(map[string]time.Time, len(instruments))
+	for _, in := range instruments {
+		if !in.ListedAt.IsZero() {
+			h.listedAt[in.Symbol] = in.ListedAt
+		}
+	}
+}
+
+// ensureOISeed дополняет историю OI данными биржи, когда подписчикам впервые
+// понадобилось окно глубже уже загруженного, чтобы алерты по OI работали сразу
+// после рестарта. Загрузка идёт в фоне: ни тик, ни мониторы её не ждут.
+func (h *Hub) ensureOISeed(ctx context.Context, depth time.Duration) {
+	src, ok := h.md.(exchanges.OIHistoryProvider)
+	if !ok {
+		return
+	}
+	h.seedMu.Lock()
+	prev := h.oiSeedDepth
+	if depth <= prev {
+		h.seedMu.Unlock()
+		return
+	}
+	h.oiSeedDepth = depth
+	h.seedMu.Unlock()
+
+	go func() {
+		if h.seedOIHistory(ctx, src, depth) {
+			return
+		}
+		// Не вышло — следующий тик попробует снова
+		h.seedMu.Lock()
+		if h.oiSeedDepth == depth {
+			h.oiSeedDepth = prev
+		}
+		h.seedMu.Unlock()
+	}()
+}
+
+// seedOIHistory скачивает историю OI по всем символам хаба глубиной depth и
+// подкладывает её под уже накопленные замеры
+func (h *Hub) seedOIHistory(ctx context.Context, src exchanges.OIHistoryProvider, depth time.Duration) bool {
+	if h.statsUsage() >= degradedUsage {
+		log.Printf("[Hub %s] Лимит запросов почти исчерпан, история OI не загружается", h.md.Name())
+		return false
+	}
+
+	limit := min(int(depth/oiSeedStep)+1, oiSeedMaxPoints)
+	symbols := h.currentSymbols()
+	snap := &MarketSnapshot{Symbols: make(map[string]*SymbolSnapshot, len(symbols))}
+	for _, sym := range symbols {
+		snap.Symbols[sym] = &SymbolSnapshot{Symbol: sym}
+	}
+
+	var mu sync.Mutex
+	seed := make(map[string][]exchanges.OIPoint, len(symbols))
+	h.forEachSymbol(ctx, snap, func(ss *SymbolSnapshot) {
+		points, err := src.OpenInterestHistory(ctx, ss.Symbol, oiSeedPeriod, limit)
+		if err != nil {
+			log.Printf("[Hub %s] Ошибка получения истории OI для %s: %v", h.md.Name(), ss.Symbol, err)
+			return
+		}
+		mu.Lock()
+		seed[ss.Symbol] = points
+		mu.Unlock()
+	})
+	if ctx.Err() != nil || len(seed) == 0 {
+		return false
+	}
+
+	capacity := oiHistoryCapacity(depth)
+	h.oiMu.Lock()
+	for sym, points := range seed {
+		recs := make([]OIRecord, len(points))
+		for i, p := range points {
+			recs[i] = OIRecord{Timestamp: p.Time, OI: p.OI}
+		}
+		h.oiHistoryFor(exchanges.CanonicalSymbol(sym), capacity).Backfill(recs)
+	}
+	h.oiMu.Unlock()
+	log.Printf("[Hub %s] Загружена история OI за %s для %d символов", h.md.Name(), depth, len(seed))
+	return true
+}
+
+// recordOI добавляет OI из полного снапшота в историю
+func (h *Hub) recordOI(snap *MarketSnapshot, capacity int) {
+	h.oiMu.Lock()
+	defer h.oiMu.Unlock()
+	for _, ss := range snap.Symbols {
+		if ss.OI == 0 {
+			continue
+		}
+		h.oiHistoryFor(ss.Instrument, capacity).Add(OIRecord{Timestamp: snap.Time, OI: ss.OI})
+	}
+}
+
+// oiHistoryFor возвращает историю инструмента не меньше capacity замеров. Вызывается под oiMu.
+func (h *Hub) oiHistoryFor(instrument string, capacity int) *OIHistory {
+	hist, ok := h.oiHistory[instrument]
+	if !ok {
+		hist = NewOIHistory(capacity)
+		h.oiHistory[instrument] = hist
+	}
+	// Подписчик мог выбрать окно длиннее прежнего
+	hist.Grow(capacity)
+	return hist
+}
+
+// OIChange — изменение OI инструмента в % относительно замера window назад
+func (h *Hub) OIChange(instrument string, current float64, now time.Time, window time.Duration) (float64, bool) {
+	h.oiMu.RLock()
+	defer h.oiMu.RUnlock()
+	hist, ok := h.oiHistory[instrument]
+	if !ok {
+		return 0, false
+	}
+	return oiChange(hist, current, now, window)
+}
+
+// forEachSymbol вызывает fn для каждого символа снапшота. Площадки, которые сами
+// следят за лимитом запросов, опрашиваются пулом воркеров, остальные — последовательно.
+func (h *Hub) forEachSymbol(ctx context.Context, snap *MarketSnapshot, fn func(ss *SymbolSnapshot)) {
+	workers := 1
+	if _, ok := h.md.(exchanges.UsageReporter); ok {
+		workers = hubWorkers
+	}
+
+	jobs := make(chan *SymbolSnapshot)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ss := range jobs {
+				fn(ss)
+			}
+		}()
+	}
+	for _, ss := range snap.Symbols {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- ss
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// usage — доля израсходованного лимита запросов площадки, 0 если площадка его не сообщает
+func (h *Hub) usage() float64 {
+	if u, ok := h.md.(exchanges.UsageReporter); ok {
+		return u.Usage()
+	}
+	return 0
+}
+
+// statsUsage — доля бюджета для запросов статистики: наибольшая из общего
+// бюджета и отдельного бюджета статистики, если площадка его ведёт
+func (h *Hub) statsUsage() float64 {
+	u := h.usage()
+	if r, ok := h.md.(exchanges.StatsUsageReporter); ok {
+		u = max(u, r.StatsUsage())
+	}
+	return u
+}
+
+func (h *Hub) publish(subs []*subscription, snap *MarketSnapshot) {
+	for _, sub := range subs {
+		select {
+		case sub.ch <- snap:
+		default:
+			log.Printf("[Hub %s] Пользователь %d не успевает обрабатывать снапшоты, тик пропущен", h.md.Name(), sub.userID)
+		}
+	}
+}
+
+// ensureKlineStream запускает стрим свечей для таймфрейма, если он ещё не запущен
+func (h *Hub) ensureKlineStream(ctx context.Context, tf string) {
+	h.liveMu.Lock()
+	defer h.liveMu.Unlock()
+	if _, ok := h.streamStop[tf]; ok {
+		return
+	}
+	h.live[tf] = make(map[string][]exchanges.Kline)
+	h.dirty[tf] = make(map[string]bool)
+	h.startKlineStream(ctx, tf)
+	log.Printf("[Hub %s] Запущен стрим свечей %s", h.md.Name(), tf)
+}
+
+// startKlineStream подписывается на свечи таймфрейма по текущему списку символов.
+// Вызывается под liveMu.
+func (h *Hub) startKlineStream(ctx context.Context, tf string) {
+	streamCtx, stop := context.WithCancel(ctx)
+	h.streamStop[tf] = stop
+	go h.stream.StreamKlines(streamCtx, h.currentSymbols(), tf, h.onKline)
+}
+
+// ensureLiquidationStream запускает стрим ликвидаций при первой подписке на эту метрику
+func (h *Hub) ensureLiquidationStream(ctx context.Context, src exchanges.LiquidationSource) {
+	h.liveMu.Lock()
+	defer h.liveMu.Unlock()
+	if h.liqStarted {
+		return
+	}
+	h.liqStarted = true
+	go src.StreamLiquidations(ctx)
+	log.Printf("[Hub %s] Запущен стрим ликвидаций", h.md.Name())
+}
+
+func (h *Hub) onKline(u exchanges.KlineUpdate) {
+	h.liveMu.Lock()
+	defer h.liveMu.Unlock()
+
+	byTF, ok := h.live[u.Interval]
+	if !ok {
+		return
+	}
+	buf := byTF[u.Symbol]
+	switch {
+	case len(buf) > 0 && buf[len(buf)-1].OpenTime.Equal(u.Kline.OpenTime):
+		buf[len(buf)-1] = u.Kline
+	case len(buf) > 0 && u.Kline.OpenTime.Before(buf[len(buf)-1].OpenTime):
+		// Запоздавшая свеча из догрузки, уже неактуальна
+		return
+	default:
+		buf = append(buf, u.Kline)
+		if len(buf) > liveKlinesDepth {
+			buf = buf[len(buf)-liveKlinesDepth:]
+		}
+	}
+	byTF[u.Symbol] = buf
+	h.dirty[u.Interval][u.Symbol] = true
+}
+
+func (h *Hub) onMarkPrice(symbol string, price float64) {
+	h.liveMu.Lock()
+	h.marks[symbol] = price
+	h.liveMu.Unlock()
+}
+
+func (h *Hub) markPrice(symbol string) (float64, bool) {
+	h.liveMu.Lock()
+	defer h.liveMu.Unlock()
+	price, ok := h.marks[symbol]
+	return price, ok
+}
+
+// flushLoop раз в streamFlushInterval рассылает свечи, обновившиеся из стрима,
+// подписчикам с соответствующим таймфреймом
+func (h *Hub) flushLoop(ctx context.Context) {
+	ticker := time.NewTicker(streamFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.flush()
+		}
+	}
+}
+
+func (h *Hub) flush() {
+	now := time.Now()
+	snaps := make(map[string]*MarketSnapshot)
+
+	h.liveMu.Lock()
+	for tf, syms := range h.dirty {
+		if len(syms) == 0 {
+			continue
+		}
+		snap := &MarketSnapshot{
+			Time:     now,
+			Exchange: h.md.Name(),
+			Symbols:  make(map[string]*SymbolSnapshot, len(syms)),
+		}
+		for sym := range syms {
+			ss := &SymbolSnapshot{
+				Symbol:     sym,
+				Instrument: exchanges.CanonicalSymbol(sym),
+				Klines:     make(map[string][]exchanges.Kline, len(h.live)),
+			}
+			// Правило может сочетать свечи разных таймфреймов и метрики REST-тика
+			for liveTF, bySym := range h.live {
+				if klines := bySym[sym]; len(klines) > 0 {
+					ss.Klines[liveTF] = append([]exchanges.Kline(nil), klines...)
+				}
+			}
+			if r, ok := h.rest[sym]; ok {
+				ss.OI = r.OI
+				ss.Funding = r.Funding
+				ss.Liquidations = r.Liquidations
+				ss.Positioning = r.Positioning
+				ss.QuoteVolume24h = r.QuoteVolume24h
+				ss.ListedAt = r.ListedAt
+			}
+			if klines := ss.Klines[tf]; len(klines) > 0 {
+				ss.Price = klines[len(klines)-1].Close
+			}
+			snap.Symbols[sym] = ss
+		}
+		h.dirty[tf] = make(map[string]bool)
+		snaps[tf] = snap
+	}
+	h.liveMu.Unlock()
+
+	if len(snaps) == 0 {
+		return
+	}
+	_, subs := h.requirements()
+	for tf, snap := range snaps {
+		var targets []*subscription
+		for _, sub := range subs {
+			if _, ok := klineTimeframes(sub.settings)[tf]; ok {
+				targets = append(targets, sub)
+			}
+		}
+		h.publish(targets, snap)
+	}
+}
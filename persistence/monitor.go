@@ -5,211 +5,426 @@ import (
 	"fmt"
 	"log"
 	"math"
-	"sync"
+	"slices"
 	"time"
 
 	"1333/internal/bots"
-	"1333/internal/exchanges/binance"
-
-	"github.com/adshao/go-binance/v2/futures"
+	"1333/internal/exchanges"
+	"1333/internal/rules"
 )
 
-type OIRecord struct {
-	Timestamp time.Time
-	OI        float64
+const alertCooldown = 5 * time.Minute // Минимальный интервал между алертами
+
+// StartMonitoring подписывает пользователя на снапшоты хаба и проверяет
+// по ним его условия до отмены ctx.
+func StartMonitoring(ctx context.Context, hub *Hub, userID int64, s bots.UserSettings, sendFunc func(int64, string)) {
+	snapshots, unsubscribe := hub.Subscribe(userID, s)
+	defer unsubscribe()
+
+	// Время открытия свечи, по которой уже был алерт: в режиме стриминга одна
+	// и та же свеча приходит много раз, алертим по ней однократно
+	priceAlerted := make(map[string]time.Time)
+	volumeAlerted := make(map[string]time.Time)
+	funding := make(map[string]*fundingState)
+	liqAlerted := make(map[string]time.Time)
+	spikeAlerted := make(map[string]time.Time)
+	ratioAlerted := make(map[string]time.Time)
+	oiAlerted := make(map[string]time.Time)
+	ruleAlerted := make(map[string]map[int]time.Time) // символ -> правило -> время алерта
+	universe := newUniverseFilter(s)
+
+	log.Printf("[User %d] Старт мониторинга", userID)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("[User %d] Завершение мониторинга", userID)
+			return
+		case snap := <-snapshots:
+			if len(snap.Listings) > 0 {
+				sendListings(userID, s, snap, sendFunc)
+				continue
+			}
+			if snap.Full {
+				// Символы, пропавшие из полного снапшота, сняты с торгов
+				pruneMissing(snap, priceAlerted, volumeAlerted, liqAlerted, spikeAlerted, ratioAlerted, oiAlerted)
+				pruneMissing(snap, funding)
+				pruneMissing(snap, ruleAlerted)
+				pruneMissing(snap, universe.excluded)
+				pruneMissing(snap, universe.oiNotional)
+			}
+			universe.refresh(snap)
+			snap = filterSymbols(snap, s, universe)
+
+			// Проверка изменения цены (только для Scalp Mode)
+			if s.TimeFrame != "" && s.ChangeThreshold > 0 {
+				checkPriceChange(userID, s, snap, priceAlerted, sendFunc)
+			}
+
+			// Проверка объёма свечи
+			if s.TimeFrame != "" && s.VolumeThreshold > 0 {
+				checkVolume(userID, s, snap, volumeAlerted, sendFunc)
+			}
+
+			// Проверка всплеска объёма относительно среднего
+			if s.VolumeTimeFrame != "" && s.VolumeMultiplier > 0 {
+				checkVolumeSpike(userID, s, snap, spikeAlerted, sendFunc)
+			}
+
+			// Проверка ставки финансирования
+			if s.FundingThreshold > 0 || s.FundingFlipAlert {
+				checkFunding(userID, s, snap, funding, sendFunc)
+			}
+
+			// Проверка ликвидаций
+			if w := liquidationWindow(s); w > 0 {
+				checkLiquidations(userID, s, w, snap, liqAlerted, sendFunc)
+			}
+
+			// Проверка соотношения лонгов/шортов
+			if positioningWindow(s) > 0 {
+				checkPositioning(userID, s, snap, ratioAlerted, sendFunc)
+			}
+
+			// Проверка изменения OI: OI замеряется только в REST-тике
+			if s.MonitorOI && s.OIThreshold > 0 && snap.Full {
+				checkOIChange(ctx, hub, userID, s, snap, oiAlerted, sendFunc)
+			}
+
+			// Проверка составных правил
+			if len(s.Rules) > 0 {
+				checkRules(hub, userID, s, snap, ruleAlerted, sendFunc)
+			}
+		}
+	}
 }
 
-type UserOITracking struct {
-	Symbols map[string]*SymbolOITracking
-	Mu      sync.Mutex
+func checkPriceChange(userID int64, s bots.UserSettings, snap *MarketSnapshot, alerted map[string]time.Time, sendFunc func(int64, string)) {
+	for sym, ss := range snap.Symbols {
+		klines := ss.Klines[s.TimeFrame]
+		if len(klines) < 2 {
+			continue
+		}
+		last := klines[len(klines)-1]
+		if alerted[sym].Equal(last.OpenTime) {
+			continue
+		}
+		prevClose, currClose := klines[len(klines)-2].Close, last.Close
+		if prevClose == 0 {
+			continue
+		}
+		cp := ((currClose - prevClose) / prevClose) * 100
+		if math.Abs(cp) >= s.ChangeThreshold {
+			d := "🟥 Dump"
+			if cp > 0 {
+				d = "🟩 Pump"
+			}
+			msg := fmt.Sprintf("%s: `%s` %s\npriceChange: %.2f%%\ncurrentlyPrice: %.4f USDT", d, ss.Instrument, snap.Exchange, cp, currClose)
+			log.Printf("[User %d] priceAlert для %s: %.2f%%", userID, sym, cp)
+			alerted[sym] = last.OpenTime
+			sendFunc(userID, msg)
+		}
+	}
 }
 
-type SymbolOITracking struct {
-	Records       []OIRecord
-	LastAlertTime time.Time // Для отслеживания времени последнего алерта
+func checkVolume(userID int64, s bots.UserSettings, snap *MarketSnapshot, alerted map[string]time.Time, sendFunc func(int64, string)) {
+	for sym, ss := range snap.Symbols {
+		klines := ss.Klines[s.TimeFrame]
+		if len(klines) == 0 {
+			continue
+		}
+		last := klines[len(klines)-1]
+		if alerted[sym].Equal(last.OpenTime) || last.QuoteVolume < s.VolumeThreshold {
+			continue
+		}
+		msg := fmt.Sprintf("📊 Volume: `%s` %s\nОбъём за %s: %.0f USDT\ncurrentlyPrice: %.4f USDT", ss.Instrument, snap.Exchange, s.TimeFrame, last.QuoteVolume, last.Close)
+		log.Printf("[User %d] volumeAlert для %s: %.0f USDT", userID, sym, last.QuoteVolume)
+		alerted[sym] = last.OpenTime
+		sendFunc(userID, msg)
+	}
 }
 
-var userOITrackings = make(map[int64]*UserOITracking)
-var uOTMu sync.Mutex
+// volumeSpike — во сколько раз объём последней свечи выше среднего за volumeBaselineCandles предыдущих
+func volumeSpike(klines []exchanges.Kline) (float64, bool) {
+	if len(klines) < volumeBaselineCandles+1 {
+		return 0, false
+	}
+	var baseline float64
+	for _, k := range klines[len(klines)-1-volumeBaselineCandles : len(klines)-1] {
+		baseline += k.QuoteVolume
+	}
+	baseline /= volumeBaselineCandles
+	if baseline == 0 {
+		return 0, false
+	}
+	return klines[len(klines)-1].QuoteVolume / baseline, true
+}
 
-const alertCooldown = 5 * time.Minute // Минимальный интервал между алертами
+func checkVolumeSpike(userID int64, s bots.UserSettings, snap *MarketSnapshot, alerted map[string]time.Time, sendFunc func(int64, string)) {
+	for sym, ss := range snap.Symbols {
+		klines := ss.Klines[s.VolumeTimeFrame]
+		ratio, ok := volumeSpike(klines)
+		if !ok || ratio < s.VolumeMultiplier {
+			continue
+		}
+		last := klines[len(klines)-1]
+		if alerted[sym].Equal(last.OpenTime) {
+			continue
+		}
 
-// Проверка значительных изменений
-func hasSignificantChange(current, previous, threshold float64) bool {
-	if previous == 0 {
-		return false // Избегаем деления на ноль
+		msg := fmt.Sprintf("🔊 Volume spike\n`%s` %s\nОбъём за %s: %.0f USDT (×%.1f к среднему за %d свечей)\ncurrentlyPrice: %.4f USDT",
+			ss.Instrument, snap.Exchange, s.VolumeTimeFrame, last.QuoteVolume, ratio, volumeBaselineCandles, last.Close)
+		log.Printf("[User %d] volumeSpike для %s: ×%.1f", userID, sym, ratio)
+		alerted[sym] = last.OpenTime
+		sendFunc(userID, msg)
 	}
-	change := math.Abs((current - previous) / previous * 100)
-	return change >= threshold
 }
 
-// Метод для проверки возможности отправки алерта
-func (s *SymbolOITracking) shouldSendAlert() bool {
-	if time.Since(s.LastAlertTime) > alertCooldown {
-		s.LastAlertTime = time.Now()
-		return true
+type fundingState struct {
+	lastRate    float64
+	alertedTill time.Time // время следующего начисления, до которого алерт по порогу уже отправлен
+	// То же для смены знака: прогнозная ставка около нуля может менять знак много раз за период
+	flippedTill time.Time
+}
+
+func checkFunding(userID int64, s bots.UserSettings, snap *MarketSnapshot, states map[string]*fundingState, sendFunc func(int64, string)) {
+	for sym, ss := range snap.Symbols {
+		if ss.Funding == nil {
+			continue
+		}
+		f := ss.Funding
+		st, ok := states[sym]
+		if !ok {
+			st = &fundingState{lastRate: f.Rate}
+			states[sym] = st
+		}
+
+		if s.FundingThreshold > 0 && math.Abs(f.Rate*100) >= s.FundingThreshold && !st.alertedTill.Equal(f.NextFundingTime) {
+			msg := fmt.Sprintf("💸 Funding Alert\n`%s` %s\nFunding rate: %.4f%%\nСледующее начисление: %s UTC",
+				ss.Instrument, snap.Exchange, f.Rate*100, f.NextFundingTime.UTC().Format("15:04"))
+			log.Printf("[User %d] fundingAlert для %s: %.4f%%", userID, sym, f.Rate*100)
+			st.alertedTill = f.NextFundingTime
+			sendFunc(userID, msg)
+		}
+
+		if s.FundingFlipAlert && st.lastRate*f.Rate < 0 && !st.flippedTill.Equal(f.NextFundingTime) {
+			msg := fmt.Sprintf("🔄 Funding flip\n`%s` %s\nFunding rate: %.4f%% → %.4f%%",
+				ss.Instrument, snap.Exchange, st.lastRate*100, f.Rate*100)
+			log.Printf("[User %d] fundingFlip для %s", userID, sym)
+			st.flippedTill = f.NextFundingTime
+			sendFunc(userID, msg)
+		}
+		if f.Rate != 0 {
+			st.lastRate = f.Rate
+		}
 	}
-	return false
 }
 
-func StartMonitoring(ctx context.Context, userID int64, s bots.UserSettings, symbols []string, sendFunc func(int64, string), c *futures.Client) {
-	ticker := time.NewTicker(time.Minute)
-	defer ticker.Stop()
+func checkLiquidations(userID int64, s bots.UserSettings, window time.Duration, snap *MarketSnapshot, alerted map[string]time.Time, sendFunc func(int64, string)) {
+	for sym, ss := range snap.Symbols {
+		liq, ok := ss.Liquidations[window]
+		if !ok || liq.Total() < s.LiqThreshold {
+			continue
+		}
+		// Одна и та же волна ликвидаций остаётся в окне, не повторяем алерт раньше его конца
+		if snap.Time.Sub(alerted[sym]) < window {
+			continue
+		}
+		msg := fmt.Sprintf("💥 Liquidations\n`%s` %s\nЗа %dm: %.0f USDT\nЛонги: %.0f USDT / Шорты: %.0f USDT",
+			ss.Instrument, snap.Exchange, s.LiqWindow, liq.Total(), liq.Long, liq.Short)
+		log.Printf("[User %d] liquidationAlert для %s: %.0f USDT", userID, sym, liq.Total())
+		alerted[sym] = snap.Time
+		sendFunc(userID, msg)
+	}
+}
 
-	uOTMu.Lock()
-	if _, exists := userOITrackings[userID]; !exists {
-		userOITrackings[userID] = &UserOITracking{
-			Symbols: make(map[string]*SymbolOITracking),
+func sendListings(userID int64, s bots.UserSettings, snap *MarketSnapshot, sendFunc func(int64, string)) {
+	for _, ev := range snap.Listings {
+		if slices.Contains(s.Blacklist, ev.Instrument) {
+			continue
 		}
-		for _, sym := range symbols {
-			currentOI, err := binance.GetOpenInterest(c, sym)
-			if err != nil {
-				log.Printf("[User %d] Ошибка инициализации OI для %s: %v", userID, sym, err)
-				continue
+		var msg string
+		switch ev.Status {
+		case exchanges.StatusTrading:
+			msg = fmt.Sprintf("🆕 New listing\n`%s` %s\nКонтракт открыт для торгов", ev.Instrument, snap.Exchange)
+		case exchanges.StatusSuspended:
+			msg = fmt.Sprintf("⚠️ Торги приостановлены\n`%s` %s\nВозможен делистинг, проверьте позиции", ev.Instrument, snap.Exchange)
+		default:
+			msg = fmt.Sprintf("⚠️ Delisting\n`%s` %s\nКонтракт перешёл в статус %s, скоро торги будут закрыты", ev.Instrument, snap.Exchange, ev.Status)
+		}
+		log.Printf("[User %d] Листинг %s: %s %s", userID, snap.Exchange, ev.Instrument, ev.Status)
+		sendFunc(userID, msg)
+	}
+}
+
+// pruneMissing удаляет из карт состояния символы, которых нет в снапшоте
+func pruneMissing[V any](snap *MarketSnapshot, states ...map[string]V) {
+	for _, m := range states {
+		for sym := range m {
+			if _, ok := snap.Symbols[sym]; !ok {
+				delete(m, sym)
 			}
-			userOITrackings[userID].Symbols[sym] = &SymbolOITracking{
-				Records: []OIRecord{
-					{
-						Timestamp: time.Now(),
-						OI:        currentOI,
-					},
-				},
-				LastAlertTime: time.Time{},
+		}
+	}
+}
+
+// filterSymbols оставляет в снапшоте только тикеры, разрешённые вотчлистом,
+// чёрным списком и фильтрами ликвидности пользователя. Снапшот общий для всех
+// подписчиков, поэтому при фильтрации создаётся копия.
+func filterSymbols(snap *MarketSnapshot, s bots.UserSettings, universe *universeFilter) *MarketSnapshot {
+	if len(s.Watchlist) == 0 && len(s.Blacklist) == 0 && len(universe.excluded) == 0 {
+		return snap
+	}
+	filtered := *snap
+	filtered.Symbols = make(map[string]*SymbolSnapshot, len(snap.Symbols))
+	for sym, ss := range snap.Symbols {
+		if s.AllowsSymbol(ss.Instrument) && universe.allows(sym) {
+			filtered.Symbols[sym] = ss
+		}
+	}
+	return &filtered
+}
+
+// oiDepth — самое длинное окно OI, нужное пользователю, 0 если OI не нужен
+func oiDepth(s bots.UserSettings) time.Duration {
+	var depth time.Duration
+	if s.MonitorOI && s.OIThreshold > 0 {
+		for _, w := range s.OILookbacks() {
+			depth = max(depth, w)
+		}
+	}
+	for _, r := range s.Rules {
+		for _, c := range r.Expr.Conditions() {
+			if c.Metric == rules.OIChange {
+				depth = max(depth, c.Duration())
 			}
-			log.Printf("[User %d] Инициализировано начальное OI для %s: %.2f", userID, sym, currentOI)
 		}
-		log.Printf("[User %d] Старт мониторинга OI для символов: %v", userID, symbols)
 	}
-	uOTMu.Unlock()
+	return depth
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			log.Printf("[User %d] Завершение мониторинга", userID)
-			return
-		case <-ticker.C:
-			// Проверка изменения цены (только для Scalp Mode)
-			if s.TimeFrame != "" && s.ChangeThreshold > 0 {
-				for _, sym := range symbols {
-					prevClose, currClose, err := binance.GetChangePercent(c, ctx, sym, s.TimeFrame)
-					if err != nil {
-						log.Printf("[User %d] Ошибка получения изменения цены для %s: %v", userID, sym, err)
-						continue
-					}
-					cp := ((currClose - prevClose) / prevClose) * 100
-					if math.Abs(cp) >= s.ChangeThreshold {
-						d := "🟥 Dump"
-						if cp > 0 {
-							d = "🟩 Pump"
-						}
-						msg := fmt.Sprintf("%s: `%s`\npriceChange: %.2f%%\ncurrentlyPrice: %.4f USDT", d, sym, cp, currClose)
-						log.Printf("[User %d] priceAlert для %s: %.2f%%", userID, sym, cp)
-						sendFunc(userID, msg)
-					}
-				}
+// oiChange — изменение OI в % относительно замера window назад
+func oiChange(h *OIHistory, current float64, now time.Time, window time.Duration) (float64, bool) {
+	rec, ok := h.Nearest(now.Add(-window), lookbackTolerance(window))
+	if !ok || rec.OI == 0 {
+		return 0, false
+	}
+	return (current - rec.OI) / rec.OI * 100, true
+}
+
+func checkOIChange(ctx context.Context, hub *Hub, userID int64, s bots.UserSettings, snap *MarketSnapshot, alerted map[string]time.Time, sendFunc func(int64, string)) {
+	md := hub.md
+	windows := s.OILookbacks()
+
+	for sym, ss := range snap.Symbols {
+		if ss.OI == 0 || snap.Time.Sub(alerted[sym]) < alertCooldown {
+			continue
+		}
+		currentOI := ss.OI
+
+		var msg string
+		for _, w := range windows {
+			change, ok := hub.OIChange(ss.Instrument, currentOI, snap.Time, w)
+			if !ok || math.Abs(change) < s.OIThreshold {
+				continue
 			}
+			msg += fmt.Sprintf("OI Change (%s): %.2f%%\n", bots.FormatWindow(w), change)
+		}
 
-			// Проверка изменения OI
-			if s.MonitorOI && s.OIThreshold > 0 {
-				uOTMu.Lock()
-				tracking, exists := userOITrackings[userID]
-				if !exists {
-					tracking = &UserOITracking{
-						Symbols: make(map[string]*SymbolOITracking),
-					}
-					for _, sym := range symbols {
-						tracking.Symbols[sym] = &SymbolOITracking{
-							Records:       []OIRecord{},
-							LastAlertTime: time.Time{},
-						}
-					}
-					userOITrackings[userID] = tracking
-					log.Printf("[User %d] Инициализация трекинга OI для символов: %v", userID, symbols)
+		if msg != "" {
+			alerted[sym] = snap.Time
+			price := ss.Price
+			if price == 0 {
+				var err error
+				price, err = md.LastPrice(ctx, sym)
+				if err != nil {
+					log.Printf("[User %d] Ошибка получения текущей цены для %s: %v", userID, sym, err)
+					continue
 				}
-				uOTMu.Unlock()
-
-				for _, sym := range symbols {
-					currentOI, err := binance.GetOpenInterest(c, sym)
-					if err != nil {
-						log.Printf("[User %d] Ошибка получения OI для %s: %v", userID, sym, err)
-						continue
-					}
-
-					tracking.Mu.Lock()
-					sTracking, ok := tracking.Symbols[sym]
-					if !ok {
-						sTracking = &SymbolOITracking{
-							Records:       []OIRecord{},
-							LastAlertTime: time.Time{},
-						}
-						tracking.Symbols[sym] = sTracking
-						log.Printf("[User %d] Добавлен трекинг OI для нового символа: %s", userID, sym)
-					}
-
-					now := time.Now()
-					sTracking.Records = append(sTracking.Records, OIRecord{
-						Timestamp: now,
-						OI:        currentOI,
-					})
-
-					// Очищаем старые записи
-					cutoff := now.Add(-30 * time.Minute)
-					var filtered []OIRecord
-					for _, rec := range sTracking.Records {
-						if rec.Timestamp.After(cutoff) {
-							filtered = append(filtered, rec)
-						}
-					}
-					sTracking.Records = filtered
-
-					var oi15m, oi30m float64
-					for _, rec := range sTracking.Records {
-				
-						if rec.Timestamp.Before(now.Add(-15*time.Minute)) && rec.Timestamp.After(now.Add(-16*time.Minute)) {
-							oi15m = rec.OI
-						}
-						if rec.Timestamp.Before(now.Add(-30*time.Minute)) && rec.Timestamp.After(now.Add(-31*time.Minute)) {
-							oi30m = rec.OI
-						}
-					}
-					tracking.Mu.Unlock()
-
-					// Если нет данных за 15 или 30 мин, пропускаем
-					if oi15m == 0 && oi30m == 0 {
-						continue
-					}
-
-					var shouldAlert bool
-					var msg string
-
-					if oi15m != 0 && hasSignificantChange(currentOI, oi15m, s.OIThreshold) {
-						if sTracking.shouldSendAlert() {
-							change15m := ((currentOI - oi15m) / oi15m) * 100
-							msg += fmt.Sprintf("OI Change (15m): %.2f%%\n", change15m)
-							shouldAlert = true
-						}
-					}
-
-					if oi30m != 0 && hasSignificantChange(currentOI, oi30m, s.OIThreshold) {
-						if sTracking.shouldSendAlert() {
-							change30m := ((currentOI - oi30m) / oi30m) * 100
-							msg += fmt.Sprintf("OI Change (30m): %.2f%%\n", change30m)
-							shouldAlert = true
-						}
-					}
-
-					if shouldAlert {
-						price, err := binance.GetCurrentPrice(c, sym)
-						if err != nil {
-							log.Printf("[User %d] Ошибка получения текущей цены для %s: %v", userID, sym, err)
-							continue
-						}
-						finalMsg := fmt.Sprintf("🎰 OI Alert\n`%s` Binance\n%sТекущая цена: %.5f USDT", sym, msg, price)
-						log.Printf("[User %d] Срабатывание по OI для %s", userID, sym)
-						sendFunc(userID, finalMsg)
-					}
+			}
+			finalMsg := fmt.Sprintf("🎰 OI Alert\n`%s` %s\n%sТекущая цена: %.5f USDT", ss.Instrument, snap.Exchange, msg, price)
+			if line := positioningLine(ss); line != "" {
+				finalMsg += "\n" + line
+			}
+			log.Printf("[User %d] Срабатывание по OI для %s", userID, sym)
+			sendFunc(userID, finalMsg)
+		}
+	}
+}
+
+// ratioValue возвращает соотношение, выбранное пользователем
+func ratioValue(metric string, p exchanges.Positioning) float64 {
+	if metric == "taker" {
+		return p.TakerBuySell
+	}
+	return p.LongShort
+}
+
+func ratioLabel(metric string) string {
+	if metric == "taker" {
+		return "Taker buy/sell"
+	}
+	return "Long/Short"
+}
+
+func checkPositioning(userID int64, s bots.UserSettings, snap *MarketSnapshot, alerted map[string]time.Time, sendFunc func(int64, string)) {
+	window := time.Duration(s.RatioWindow) * time.Minute
+	for sym, ss := range snap.Symbols {
+		series := ss.Positioning
+		if len(series) < 2 {
+			continue
+		}
+		last := series[len(series)-1]
+		if alerted[sym].Equal(last.Time) {
+			continue
+		}
+		curr := ratioValue(s.RatioMetric, last)
+		prev := ratioValue(s.RatioMetric, series[len(series)-2])
+		if curr == 0 || prev == 0 {
+			continue
+		}
+
+		var msg string
+		if s.RatioCross > 0 && (prev-s.RatioCross)*(curr-s.RatioCross) < 0 {
+			dir := "вниз"
+			if curr > prev {
+				dir = "вверх"
+			}
+			msg = fmt.Sprintf("Пересечение %.2f %s: %.2f → %.2f", s.RatioCross, dir, prev, curr)
+		} else if s.RatioShift > 0 && window > 0 {
+			// Самая старая точка внутри окна
+			var base float64
+			for _, p := range series {
+				if !p.Time.Before(last.Time.Add(-window)) {
+					base = ratioValue(s.RatioMetric, p)
+					break
 				}
 			}
+			if base == 0 {
+				continue
+			}
+			change := (curr - base) / base * 100
+			if math.Abs(change) < s.RatioShift {
+				continue
+			}
+			msg = fmt.Sprintf("Изменение за %dm: %.2f%% (%.2f → %.2f)", s.RatioWindow, change, base, curr)
 		}
+		if msg == "" {
+			continue
+		}
+
+		text := fmt.Sprintf("⚖️ %s\n`%s` %s\n%s", ratioLabel(s.RatioMetric), ss.Instrument, snap.Exchange, msg)
+		log.Printf("[User %d] positioningAlert для %s: %.2f", userID, sym, curr)
+		alerted[sym] = last.Time
+		sendFunc(userID, text)
+	}
+}
+
+// positioningLine — строка с текущим позиционированием для OI алерта. Берём
+// только то, что собрал хаб: отдельный запрос на каждый алерт тратит лимит /futures/data.
+func positioningLine(ss *SymbolSnapshot) string {
+	if len(ss.Positioning) == 0 {
+		return ""
 	}
+	last := ss.Positioning[len(ss.Positioning)-1]
+	return fmt.Sprintf("Long/Short: %.2f | Taker buy/sell: %.2f", last.LongShort, last.TakerBuySell)
 }
@@ -0,0 +1,189 @@
+package persistence
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"1333/internal/bots"
+	"1333/internal/exchanges"
+	"1333/internal/exchanges/exchangestest"
+	"1333/internal/rules"
+)
+
+// startMonitor запускает монитор пользователя на хабе и ждёт, пока он подпишется.
+// Алерты приходят в возвращаемый канал.
+func startMonitor(t *testing.T, hub *Hub, s bots.UserSettings) <-chan string {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	alerts := make(chan string, 16)
+	go StartMonitoring(ctx, hub, 1, s, func(_ int64, msg string) { alerts <- msg })
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		hub.mu.Lock()
+		n := len(hub.subs)
+		hub.mu.Unlock()
+		if n > 0 {
+			return alerts
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("монитор не подписался на хаб")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func waitAlert(t *testing.T, alerts <-chan string) string {
+	t.Helper()
+	select {
+	case msg := <-alerts:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("алерт не пришёл")
+		return ""
+	}
+}
+
+func klines(closes ...float64) []exchanges.Kline {
+	open := time.Now().Truncate(5 * time.Minute).Add(-time.Duration(len(closes)-1) * 5 * time.Minute)
+	out := make([]exchanges.Kline, len(closes))
+	for i, c := range closes {
+		out[i] = exchanges.Kline{OpenTime: open.Add(time.Duration(i) * 5 * time.Minute), Close: c}
+	}
+	return out
+}
+
+func TestStartMonitoringPriceAlert(t *testing.T) {
+	md := exchangestest.NewFake("Fake", "BTCUSDT", "ETHUSDT")
+	md.SetKlines("BTCUSDT", "5m", klines(100, 105))
+	md.SetKlines("ETHUSDT", "5m", klines(100, 101))
+	hub := NewHub(md, []string{"BTCUSDT", "ETHUSDT"})
+
+	alerts := startMonitor(t, hub, bots.UserSettings{Mode: "scalp", ChangeThreshold: 3, TimeFrame: "5m", TargetBot: "bot1"})
+	hub.tick(context.Background())
+
+	msg := waitAlert(t, alerts)
+	if !strings.Contains(msg, "Pump") || !strings.Contains(msg, "BTCUSDT") {
+		t.Fatalf("неожиданный алерт: %q", msg)
+	}
+
+	// Та же свеча повторно не алертит, ETH не дотягивает до порога
+	hub.tick(context.Background())
+	select {
+	case msg := <-alerts:
+		t.Fatalf("лишний алерт: %q", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestStreamingFlushCarriesRESTMetrics(t *testing.T) {
+	md := exchangestest.NewFake("Fake", "BTCUSDT")
+	hub := NewHub(md, []string{"BTCUSDT"})
+	hub.EnableStreaming(stubStream{})
+
+	expr, err := rules.Parse("price.change(5m) > 3% and oi.change(15m) > 5%")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := bots.UserSettings{Mode: "intraday", TargetBot: "bot1", Rules: []rules.Rule{{ID: 1, Expr: expr}}}
+
+	// История OI: 15 минут назад OI был 100, сейчас 110
+	now := time.Now()
+	hub.oiHistoryFor("BTCUSDT", oiHistoryCapacity(15*time.Minute)).Add(OIRecord{Timestamp: now.Add(-15 * time.Minute), OI: 100})
+	md.SetOpenInterest("BTCUSDT", 110)
+
+	alerts := startMonitor(t, hub, s)
+	hub.tick(context.Background())
+
+	// Свечи приходят из стрима, полного снапшота без них правилу недостаточно
+	for _, k := range klines(100, 105) {
+		hub.onKline(exchanges.KlineUpdate{Symbol: "BTCUSDT", Interval: "5m", Kline: k})
+	}
+	hub.flush()
+
+	msg := waitAlert(t, alerts)
+	if !strings.Contains(msg, "Правило #1") || !strings.Contains(msg, "oi.change(15m)") {
+		t.Fatalf("неожиданный алерт: %q", msg)
+	}
+}
+
+// stubStream включает хабу режим стриминга; сами стримы тест подаёт через onKline
+type stubStream struct{}
+
+func (stubStream) StreamKlines(ctx context.Context, symbols []string, interval string, handler func(exchanges.KlineUpdate)) {
+}
+
+func (stubStream) StreamMarkPrices(ctx context.Context, handler func(symbol string, price float64)) {}
+
+func TestFundingFlipOncePerPeriod(t *testing.T) {
+	next := time.Now().Truncate(time.Hour).Add(time.Hour)
+	s := bots.UserSettings{FundingFlipAlert: true}
+	states := make(map[string]*fundingState)
+
+	var sent []string
+	send := func(_ int64, msg string) { sent = append(sent, msg) }
+	for _, rate := range []float64{0.0001, -0.0001, 0.0001, -0.0002} {
+		snap := &MarketSnapshot{Exchange: "Fake", Symbols: map[string]*SymbolSnapshot{
+			"BTCUSDT": {Symbol: "BTCUSDT", Instrument: "BTCUSDT", Funding: &exchanges.Funding{Rate: rate, NextFundingTime: next}},
+		}}
+		checkFunding(1, s, snap, states, send)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("за один период %d алертов о смене знака, want 1", len(sent))
+	}
+
+	// В следующем периоде смена знака снова алертит
+	snap := &MarketSnapshot{Exchange: "Fake", Symbols: map[string]*SymbolSnapshot{
+		"BTCUSDT": {Symbol: "BTCUSDT", Instrument: "BTCUSDT", Funding: &exchanges.Funding{Rate: 0.0001, NextFundingTime: next.Add(8 * time.Hour)}},
+	}}
+	checkFunding(1, s, snap, states, send)
+	if len(sent) != 2 {
+		t.Fatalf("в новом периоде алертов %d, want 2", len(sent))
+	}
+}
+
+// oiHistoryFake отдаёт историю OI и считает запросы к ней
+type oiHistoryFake struct {
+	*exchangestest.Fake
+	calls atomic.Int32
+}
+
+func (f *oiHistoryFake) OpenInterestHistory(ctx context.Context, symbol, period string, limit int) ([]exchanges.OIPoint, error) {
+	f.calls.Add(1)
+	now := time.Now()
+	return []exchanges.OIPoint{{Time: now.Add(-30 * time.Minute), OI: 100}, {Time: now.Add(-15 * time.Minute), OI: 100}}, nil
+}
+
+func TestHubSeedsOIHistoryOnce(t *testing.T) {
+	md := &oiHistoryFake{Fake: exchangestest.NewFake("Fake", "BTCUSDT")}
+	md.SetOpenInterest("BTCUSDT", 110)
+	hub := NewHub(md, []string{"BTCUSDT"})
+
+	s := bots.UserSettings{Mode: "intraday", MonitorOI: true, OIThreshold: 5, TargetBot: "bot1"}
+	startMonitor(t, hub, s)
+	startMonitor(t, hub, s)
+	if n := md.calls.Load(); n != 0 {
+		t.Fatalf("старт монитора запросил историю OI %d раз, а должен хаб", n)
+	}
+
+	hub.tick(context.Background())
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok := hub.OIChange("BTCUSDT", 110, time.Now(), 15*time.Minute); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("история OI не загрузилась")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	hub.tick(context.Background())
+	if n := md.calls.Load(); n != 1 {
+		t.Fatalf("история OI запрошена %d раз, want 1", n)
+	}
+}
@@ -0,0 +1,118 @@
+package persistence
+
+import (
+	"sort"
+	"time"
+)
+
+type OIRecord struct {
+	Timestamp time.Time
+	OI        float64
+}
+
+// OIHistory — кольцевой буфер замеров OI, упорядоченных по времени.
+// Когда буфер заполнен, новый замер вытесняет самый старый.
+type OIHistory struct {
+	records []OIRecord
+	start   int // индекс самого старого замера
+	size    int
+}
+
+func NewOIHistory(capacity int) *OIHistory {
+	return &OIHistory{records: make([]OIRecord, max(capacity, 1))}
+}
+
+// oiHistoryCapacity — сколько замеров нужно хранить, чтобы покрыть самое длинное окно
+func oiHistoryCapacity(longest time.Duration) int {
+	return int(longest/hubTickInterval) + 2
+}
+
+func (h *OIHistory) at(i int) OIRecord {
+	return h.records[(h.start+i)%len(h.records)]
+}
+
+func (h *OIHistory) Len() int {
+	return h.size
+}
+
+// Grow увеличивает ёмкость буфера, сохраняя накопленные замеры
+func (h *OIHistory) Grow(capacity int) {
+	if capacity <= len(h.records) {
+		return
+	}
+	records := make([]OIRecord, capacity)
+	for i := 0; i < h.size; i++ {
+		records[i] = h.at(i)
+	}
+	h.records, h.start = records, 0
+}
+
+// Add добавляет замер. Замеры не новее последнего игнорируются.
+func (h *OIHistory) Add(rec OIRecord) {
+	if h.size > 0 && !rec.Timestamp.After(h.at(h.size-1).Timestamp) {
+		return
+	}
+	if h.size < len(h.records) {
+		h.records[(h.start+h.size)%len(h.records)] = rec
+		h.size++
+		return
+	}
+	h.records[h.start] = rec
+	h.start = (h.start + 1) % len(h.records)
+}
+
+// Backfill дописывает в начало буфера замеры старше самого старого из имеющихся,
+// например историю с биржи под живые замеры. Места хватает только на
+// самые свежие из них.
+func (h *OIHistory) Backfill(recs []OIRecord) {
+	var older []OIRecord
+	for _, rec := range recs {
+		if h.size == 0 || rec.Timestamp.Before(h.at(0).Timestamp) {
+			older = append(older, rec)
+		}
+	}
+	if len(older) == 0 {
+		return
+	}
+	merged := &OIHistory{records: make([]OIRecord, len(h.records))}
+	for _, rec := range older[max(len(older)-(len(h.records)-h.size), 0):] {
+		merged.Add(rec)
+	}
+	for i := 0; i < h.size; i++ {
+		merged.Add(h.at(i))
+	}
+	*h = *merged
+}
+
+// Nearest возвращает замер, ближайший по времени к t, если он отстоит от t
+// не больше чем на tolerance.
+func (h *OIHistory) Nearest(t time.Time, tolerance time.Duration) (OIRecord, bool) {
+	if h.size == 0 {
+		return OIRecord{}, false
+	}
+	i := sort.Search(h.size, func(i int) bool { return !h.at(i).Timestamp.Before(t) })
+
+	best, found := OIRecord{}, false
+	bestDiff := tolerance + 1
+	for _, j := range []int{i - 1, i} {
+		if j < 0 || j >= h.size {
+			continue
+		}
+		rec := h.at(j)
+		diff := rec.Timestamp.Sub(t)
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff <= tolerance && diff < bestDiff {
+			best, bestDiff, found = rec, diff, true
+		}
+	}
+	return best, found
+}
+
+// lookbackTolerance — насколько найденный замер может отстоять от нужного момента.
+// Для длинных окон допускаем больший разброс, но не меньше половины шага
+// загруженной с биржи истории, иначе она не находилась бы для коротких окон.
+func lookbackTolerance(window time.Duration) time.Duration {
+	return max(hubTickInterval, oiSeedStep/2, window/20)
+}
@@ -0,0 +1,146 @@
+package persistence
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func rec(min int, oi float64) OIRecord {
+	return OIRecord{Timestamp: t0.Add(time.Duration(min) * time.Minute), OI: oi}
+}
+
+// values возвращает OI всех замеров буфера от старых к новым
+func values(h *OIHistory) []float64 {
+	var out []float64
+	for i := 0; i < h.Len(); i++ {
+		out = append(out, h.at(i).OI)
+	}
+	return out
+}
+
+func TestOIHistoryAdd(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		add      []OIRecord
+		want     []float64
+	}{
+		{"пустой", 3, nil, nil},
+		{"меньше ёмкости", 3, []OIRecord{rec(0, 1), rec(1, 2)}, []float64{1, 2}},
+		{"вытеснение старых", 3, []OIRecord{rec(0, 1), rec(1, 2), rec(2, 3), rec(3, 4), rec(4, 5)}, []float64{3, 4, 5}},
+		{"тот же момент игнорируется", 3, []OIRecord{rec(0, 1), rec(0, 2)}, []float64{1}},
+		{"замер старше последнего игнорируется", 3, []OIRecord{rec(5, 1), rec(3, 2), rec(6, 3)}, []float64{1, 3}},
+		{"нулевая ёмкость", 0, []OIRecord{rec(0, 1), rec(1, 2)}, []float64{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewOIHistory(tt.capacity)
+			for _, r := range tt.add {
+				h.Add(r)
+			}
+			if got := values(h); !slices.Equal(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOIHistoryNearest(t *testing.T) {
+	h := NewOIHistory(10)
+	for _, r := range []OIRecord{rec(0, 1), rec(5, 2), rec(10, 3)} {
+		h.Add(r)
+	}
+	tests := []struct {
+		name      string
+		at        time.Duration
+		tolerance time.Duration
+		want      float64
+		wantOK    bool
+	}{
+		{"точное совпадение", 5 * time.Minute, 0, 2, true},
+		{"ближе к предыдущему", 7 * time.Minute, 3 * time.Minute, 2, true},
+		{"ближе к следующему", 8 * time.Minute, 3 * time.Minute, 3, true},
+		{"раньше первого", -time.Minute, time.Minute, 1, true},
+		{"позже последнего", 11 * time.Minute, time.Minute, 3, true},
+		{"вне допуска", 7 * time.Minute, time.Minute, 0, false},
+		{"далеко в прошлом", -time.Hour, time.Minute, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := h.Nearest(t0.Add(tt.at), tt.tolerance)
+			if ok != tt.wantOK || got.OI != tt.want {
+				t.Fatalf("got %v %v, want %v %v", got.OI, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	if _, ok := NewOIHistory(3).Nearest(t0, time.Hour); ok {
+		t.Fatal("в пустой истории нашёлся замер")
+	}
+}
+
+func TestOIHistoryNearestAfterWrap(t *testing.T) {
+	h := NewOIHistory(3)
+	for i := 0; i < 5; i++ {
+		h.Add(rec(i, float64(i)))
+	}
+	if got, ok := h.Nearest(t0.Add(2*time.Minute), 0); !ok || got.OI != 2 {
+		t.Fatalf("got %v %v, want 2", got.OI, ok)
+	}
+	if _, ok := h.Nearest(t0.Add(time.Minute), 0); ok {
+		t.Fatal("вытесненный замер всё ещё находится")
+	}
+}
+
+func TestOIHistoryGrow(t *testing.T) {
+	h := NewOIHistory(3)
+	for i := 0; i < 5; i++ {
+		h.Add(rec(i, float64(i)))
+	}
+	h.Grow(2)
+	if got := values(h); !slices.Equal(got, []float64{2, 3, 4}) {
+		t.Fatalf("уменьшение ёмкости изменило буфер: %v", got)
+	}
+
+	h.Grow(5)
+	if got := values(h); !slices.Equal(got, []float64{2, 3, 4}) {
+		t.Fatalf("после Grow потерялись замеры: %v", got)
+	}
+	for i := 5; i < 8; i++ {
+		h.Add(rec(i, float64(i)))
+	}
+	if got := values(h); !slices.Equal(got, []float64{3, 4, 5, 6, 7}) {
+		t.Fatalf("после Grow буфер вытесняет не те замеры: %v", got)
+	}
+}
+
+func TestOIHistoryBackfill(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		live     []OIRecord
+		seed     []OIRecord
+		want     []float64
+	}{
+		{"пустая история", 5, nil, []OIRecord{rec(0, 1), rec(5, 2)}, []float64{1, 2}},
+		{"под живые замеры", 5, []OIRecord{rec(10, 3), rec(11, 4)}, []OIRecord{rec(0, 1), rec(5, 2), rec(10, 9)}, []float64{1, 2, 3, 4}},
+		{"только свежие из истории", 3, []OIRecord{rec(10, 3)}, []OIRecord{rec(0, 0), rec(5, 1), rec(7, 2)}, []float64{1, 2, 3}},
+		{"буфер полон", 2, []OIRecord{rec(10, 3), rec(11, 4)}, []OIRecord{rec(0, 1)}, []float64{3, 4}},
+		{"история новее живых", 5, []OIRecord{rec(0, 1)}, []OIRecord{rec(5, 2)}, []float64{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewOIHistory(tt.capacity)
+			for _, r := range tt.live {
+				h.Add(r)
+			}
+			h.Backfill(tt.seed)
+			if got := values(h); !slices.Equal(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
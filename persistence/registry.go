@@ -0,0 +1,212 @@
+package persistence
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"1333/internal/bots"
+)
+
+// MonitorRegistry владеет мониторами пользователей: у каждого пользователя не больше
+// одного запущенного монитора, и новая настройка заменяет старую, а не добавляется к ней.
+type MonitorRegistry struct {
+	ctx  context.Context
+	hubs map[string]*Hub
+	send func(botName string, userID int64, text string)
+
+	mu       sync.Mutex
+	monitors map[int64]*userMonitor
+
+	statsMu sync.Mutex
+	stats   map[int64]*alertStats
+}
+
+// alertStats — сколько алертов ушло пользователю, для /status
+type alertStats struct {
+	last  time.Time
+	day   time.Time // начало суток по UTC, к которым относится count
+	count int
+}
+
+type userMonitor struct {
+	settings bots.UserSettings
+	cancel   context.CancelFunc // nil, если монитор на паузе
+	done     chan struct{}      // закрывается, когда все горутины монитора завершились
+	timer    *time.Timer        // автоматическое снятие паузы
+}
+
+// NewMonitorRegistry создаёт реестр. Мониторы живут не дольше ctx, алерты
+// отправляются через send в бота из настроек пользователя.
+func NewMonitorRegistry(ctx context.Context, hubs map[string]*Hub, send func(botName string, userID int64, text string)) *MonitorRegistry {
+	return &MonitorRegistry{
+		ctx:      ctx,
+		hubs:     hubs,
+		send:     send,
+		monitors: make(map[int64]*userMonitor),
+		stats:    make(map[int64]*alertStats),
+	}
+}
+
+// Apply приводит монитор пользователя в соответствие с настройками: запускает его,
+// ставит на паузу до s.PausedUntil или останавливает.
+func (r *MonitorRegistry) Apply(userID int64, s bots.UserSettings) {
+	switch {
+	case !s.HasMonitoring() || s.Stopped:
+		r.Stop(userID)
+	case time.Now().Before(s.PausedUntil):
+		r.Pause(userID, s, s.PausedUntil)
+	default:
+		r.Start(userID, s)
+	}
+}
+
+// Start запускает мониторинг с настройками s, останавливая прежний монитор пользователя.
+// Новый монитор начинает работу только после полной остановки старого, поэтому
+// дублей алертов при перенастройке не бывает.
+func (r *MonitorRegistry) Start(userID int64, s bots.UserSettings) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.monitors[userID] = r.launch(userID, s, r.halt(userID))
+}
+
+// Stop останавливает мониторинг и забывает пользователя
+func (r *MonitorRegistry) Stop(userID int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.monitors[userID]; !ok {
+		return
+	}
+	r.halt(userID)
+	delete(r.monitors, userID)
+	log.Printf("[User %d] Мониторинг остановлен", userID)
+}
+
+// Pause останавливает мониторинг, запоминая настройки для Resume.
+// Если until не нулевое, мониторинг возобновится сам в этот момент.
+func (r *MonitorRegistry) Pause(userID int64, s bots.UserSettings, until time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m := &userMonitor{settings: s, done: r.halt(userID)}
+	if !until.IsZero() {
+		m.timer = time.AfterFunc(time.Until(until), func() { r.resume(userID, m) })
+	}
+	r.monitors[userID] = m
+	log.Printf("[User %d] Мониторинг на паузе до %s", userID, until.Format(time.DateTime))
+}
+
+// Resume снова запускает мониторинг, поставленный на паузу
+func (r *MonitorRegistry) Resume(userID int64) {
+	r.resume(userID, nil)
+}
+
+// resume возобновляет паузу; если want не nil, только если это всё ещё та же пауза,
+// а не более поздняя, выставленная уже после срабатывания таймера
+func (r *MonitorRegistry) resume(userID int64, want *userMonitor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.monitors[userID]
+	if !ok || m.cancel != nil || (want != nil && m != want) {
+		return
+	}
+	r.monitors[userID] = r.launch(userID, m.settings, r.halt(userID))
+	log.Printf("[User %d] Мониторинг возобновлён", userID)
+}
+
+// Running — работает ли сейчас монитор пользователя
+func (r *MonitorRegistry) Running(userID int64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.monitors[userID]
+	return ok && m.cancel != nil
+}
+
+// Status отдаёт состояние монитора и статистику алертов пользователя
+func (r *MonitorRegistry) Status(userID int64) bots.MonitorStatus {
+	st := bots.MonitorStatus{Running: r.Running(userID)}
+
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	if a, ok := r.stats[userID]; ok {
+		st.LastAlert = a.last
+		if a.day.Equal(startOfDay(time.Now())) {
+			st.AlertsToday = a.count
+		}
+	}
+	return st
+}
+
+// countAlert учитывает отправленный пользователю алерт
+func (r *MonitorRegistry) countAlert(userID int64) {
+	now := time.Now()
+	day := startOfDay(now)
+
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	a, ok := r.stats[userID]
+	if !ok {
+		a = &alertStats{}
+		r.stats[userID] = a
+	}
+	if !a.day.Equal(day) {
+		a.day, a.count = day, 0
+	}
+	a.last = now
+	a.count++
+}
+
+func startOfDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// halt гасит текущий монитор пользователя и его таймер паузы. Возвращает канал,
+// закрывающийся после остановки, или nil. Вызывается под mu.
+func (r *MonitorRegistry) halt(userID int64) chan struct{} {
+	m, ok := r.monitors[userID]
+	if !ok {
+		return nil
+	}
+	if m.cancel != nil {
+		m.cancel()
+	}
+	if m.timer != nil {
+		m.timer.Stop()
+	}
+	return m.done
+}
+
+// launch запускает мониторинг по всем биржам пользователя, дождавшись завершения
+// предыдущего монитора prev. Вызывается под mu.
+func (r *MonitorRegistry) launch(userID int64, s bots.UserSettings, prev chan struct{}) *userMonitor {
+	ctx, cancel := context.WithCancel(r.ctx)
+	m := &userMonitor{settings: s, cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(m.done)
+		if prev != nil {
+			<-prev
+		}
+
+		var wg sync.WaitGroup
+		for _, ex := range s.Exchanges() {
+			hub, ok := r.hubs[ex]
+			if !ok {
+				log.Printf("Пользователь %d: биржа %s недоступна", userID, ex)
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				StartMonitoring(ctx, hub, userID, s, func(u int64, text string) {
+					r.countAlert(u)
+					r.send(s.TargetBot, u, text)
+				})
+			}()
+		}
+		wg.Wait()
+	}()
+	return m
+}
@@ -0,0 +1,97 @@
+package persistence
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"1333/internal/bots"
+	"1333/internal/exchanges/exchangestest"
+)
+
+func newTestRegistry(t *testing.T) (*MonitorRegistry, *Hub) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	hub := NewHub(exchangestest.NewFake("Fake", "BTCUSDT"), []string{"BTCUSDT"})
+	r := NewMonitorRegistry(ctx, map[string]*Hub{"binance": hub}, func(string, int64, string) {})
+	return r, hub
+}
+
+// subscribed — настройки всех текущих подписчиков хаба
+func subscribed(hub *Hub) []bots.UserSettings {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	var out []bots.UserSettings
+	for sub := range hub.subs {
+		out = append(out, sub.settings)
+	}
+	return out
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal(what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func scalpSettings(threshold float64) bots.UserSettings {
+	return bots.UserSettings{Mode: "scalp", ChangeThreshold: threshold, TimeFrame: "5m", TargetBot: "bot1"}
+}
+
+func TestRegistryApplyRestartsMonitor(t *testing.T) {
+	r, hub := newTestRegistry(t)
+
+	r.Apply(1, scalpSettings(3))
+	waitFor(t, "монитор не запустился", func() bool { return len(subscribed(hub)) == 1 })
+
+	r.Apply(1, scalpSettings(5))
+	waitFor(t, "монитор не перезапустился с новыми настройками", func() bool {
+		subs := subscribed(hub)
+		return len(subs) == 1 && subs[0].ChangeThreshold == 5
+	})
+	if !r.Running(1) {
+		t.Fatal("после перезапуска монитор не работает")
+	}
+}
+
+func TestRegistryPauseTimerResumes(t *testing.T) {
+	r, hub := newTestRegistry(t)
+
+	r.Start(1, scalpSettings(3))
+	waitFor(t, "монитор не запустился", func() bool { return len(subscribed(hub)) == 1 })
+
+	r.Pause(1, scalpSettings(3), time.Now().Add(50*time.Millisecond))
+	if r.Running(1) {
+		t.Fatal("монитор работает на паузе")
+	}
+	waitFor(t, "монитор не остановился на паузе", func() bool { return len(subscribed(hub)) == 0 })
+
+	waitFor(t, "пауза не снялась по таймеру", func() bool { return r.Running(1) && len(subscribed(hub)) == 1 })
+}
+
+func TestRegistryStopWhileStarting(t *testing.T) {
+	r, hub := newTestRegistry(t)
+
+	// Новый монитор ждёт остановки старого, Stop приходит, пока оба ещё стартуют
+	for i := 0; i < 50; i++ {
+		r.Start(1, scalpSettings(3))
+		r.Start(1, scalpSettings(5))
+		r.Stop(1)
+	}
+	if r.Running(1) {
+		t.Fatal("монитор работает после Stop")
+	}
+	waitFor(t, "после Stop остались подписчики", func() bool { return len(subscribed(hub)) == 0 })
+
+	// Опоздавший монитор не должен подписаться уже после остановки
+	time.Sleep(20 * time.Millisecond)
+	if n := len(subscribed(hub)); n != 0 {
+		t.Fatalf("после Stop подписчиков %d, want 0", n)
+	}
+}
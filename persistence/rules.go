@@ -0,0 +1,94 @@
+package persistence
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"1333/internal/bots"
+	"1333/internal/rules"
+)
+
+// symbolValues отдаёт правилам значения метрик одного символа из снапшота
+type symbolValues struct {
+	ss  *SymbolSnapshot
+	hub *Hub // история OI; nil — изменение OI неизвестно
+	now time.Time
+}
+
+func (v symbolValues) Value(c rules.Condition) (float64, bool) {
+	ss := v.ss
+	switch c.Metric {
+	case rules.PriceChange:
+		klines := ss.Klines[c.Window]
+		if len(klines) < 2 {
+			return 0, false
+		}
+		prevClose, currClose := klines[len(klines)-2].Close, klines[len(klines)-1].Close
+		if prevClose == 0 {
+			return 0, false
+		}
+		return (currClose - prevClose) / prevClose * 100, true
+	case rules.Volume:
+		klines := ss.Klines[c.Window]
+		if len(klines) == 0 {
+			return 0, false
+		}
+		return klines[len(klines)-1].QuoteVolume, true
+	case rules.VolumeSpike:
+		return volumeSpike(ss.Klines[c.Window])
+	case rules.OIChange:
+		if ss.OI == 0 || v.hub == nil {
+			return 0, false
+		}
+		return v.hub.OIChange(ss.Instrument, ss.OI, v.now, c.Duration())
+	case rules.Funding:
+		if ss.Funding == nil {
+			return 0, false
+		}
+		return ss.Funding.Rate * 100, true
+	case rules.Liquidations:
+		liq, ok := ss.Liquidations[c.Duration()]
+		return liq.Total(), ok
+	case rules.LongShort, rules.TakerBuySell:
+		if len(ss.Positioning) == 0 {
+			return 0, false
+		}
+		last := ss.Positioning[len(ss.Positioning)-1]
+		if c.Metric == rules.TakerBuySell {
+			return last.TakerBuySell, last.TakerBuySell != 0
+		}
+		return last.LongShort, last.LongShort != 0
+	}
+	return 0, false
+}
+
+func checkRules(hub *Hub, userID int64, s bots.UserSettings, snap *MarketSnapshot, alerted map[string]map[int]time.Time, sendFunc func(int64, string)) {
+	for sym, ss := range snap.Symbols {
+		v := symbolValues{ss: ss, hub: hub, now: snap.Time}
+
+		for _, r := range s.Rules {
+			if snap.Time.Sub(alerted[sym][r.ID]) < alertCooldown || !r.Expr.Eval(v) {
+				continue
+			}
+
+			var lines []string
+			for _, c := range r.Expr.Conditions() {
+				if val, ok := v.Value(c); ok {
+					lines = append(lines, fmt.Sprintf("`%s`: %.2f", c, val))
+				}
+			}
+			msg := fmt.Sprintf("🧩 Правило #%d\n`%s` %s\n%s", r.ID, ss.Instrument, snap.Exchange, strings.Join(lines, "\n"))
+			if ss.Price > 0 {
+				msg += fmt.Sprintf("\nТекущая цена: %.5f USDT", ss.Price)
+			}
+			log.Printf("[User %d] Срабатывание правила #%d для %s", userID, r.ID, sym)
+			if alerted[sym] == nil {
+				alerted[sym] = make(map[int]time.Time)
+			}
+			alerted[sym][r.ID] = snap.Time
+			sendFunc(userID, msg)
+		}
+	}
+}
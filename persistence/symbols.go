@@ -0,0 +1,171 @@
+package persistence
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+
+	"1333/internal/exchanges"
+)
+
+// Как часто хаб перечитывает список контрактов площадки
+const symbolsRefreshInterval = 15 * time.Minute
+
+func (h *Hub) currentSymbols() []string {
+	h.symbolsMu.RLock()
+	defer h.symbolsMu.RUnlock()
+	return h.symbols
+}
+
+// refreshSymbols перечитывает список контрактов: новые символы начинают опрашиваться
+// со следующего тика, по исчезнувшим (делистинг, экспирация) сбрасывается всё
+// накопленное состояние. Вызывается из Run между тиками.
+func (h *Hub) refreshSymbols(ctx context.Context) {
+	h.symbolsRefreshed = time.Now()
+
+	var fresh []string
+	var listedAt map[string]time.Time
+	statuses := make(map[string]string)
+	if src, ok := h.md.(exchanges.InstrumentProvider); ok {
+		instruments, err := src.Instruments(ctx)
+		if err != nil {
+			log.Printf("[Hub %s] Ошибка обновления списка символов: %v", h.md.Name(), err)
+			return
+		}
+		listedAt = make(map[string]time.Time, len(instruments))
+		for _, in := range instruments {
+			statuses[in.Symbol] = in.Status
+			if in.Status == exchanges.StatusTrading {
+				fresh = append(fresh, in.Symbol)
+			}
+			if !in.ListedAt.IsZero() {
+				listedAt[in.Symbol] = in.ListedAt
+			}
+		}
+	} else {
+		var err error
+		if fresh, err = h.md.Symbols(ctx); err != nil {
+			log.Printf("[Hub %s] Ошибка обновления списка символов: %v", h.md.Name(), err)
+			return
+		}
+	}
+	// Пустой ответ скорее сбой площадки, чем делистинг всего рынка
+	if len(fresh) == 0 {
+		log.Printf("[Hub %s] Площадка вернула пустой список символов, оставляем прежний", h.md.Name())
+		return
+	}
+
+	old := h.currentSymbols()
+	var added, removed []string
+	for _, sym := range fresh {
+		if !slices.Contains(old, sym) {
+			added = append(added, sym)
+		}
+	}
+	for _, sym := range old {
+		if !slices.Contains(fresh, sym) {
+			removed = append(removed, sym)
+		}
+	}
+
+	if h.listedAt != nil && listedAt != nil {
+		h.listedAt = listedAt
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	log.Printf("[Hub %s] Список символов обновлён: +%d %v, -%d %v", h.md.Name(), len(added), added, len(removed), removed)
+
+	h.symbolsMu.Lock()
+	h.symbols = fresh
+	h.symbolsMu.Unlock()
+
+	h.dropSymbols(removed)
+	h.restartKlineStreams(ctx)
+	h.announceListings(added, removed, statuses)
+}
+
+// ListingEvent — контракт вышел в торги или ушёл с них
+type ListingEvent struct {
+	Symbol     string
+	Instrument string
+	Status     string // exchanges.StatusTrading для листинга, иначе новый статус контракта
+}
+
+// announceListings рассылает подписавшимся на листинги пользователям новые
+// контракты и контракты, ушедшие на расчёт, поставку или приостановку торгов.
+// Контракты, пропавшие из ответа площадки целиком, не анонсируются: статуса у них нет.
+func (h *Hub) announceListings(added, removed []string, statuses map[string]string) {
+	var events []ListingEvent
+	for _, sym := range added {
+		events = append(events, ListingEvent{Symbol: sym, Instrument: exchanges.CanonicalSymbol(sym), Status: exchanges.StatusTrading})
+	}
+	for _, sym := range removed {
+		switch st := statuses[sym]; st {
+		case exchanges.StatusSettling, exchanges.StatusDelivering, exchanges.StatusSuspended:
+			events = append(events, ListingEvent{Symbol: sym, Instrument: exchanges.CanonicalSymbol(sym), Status: st})
+		}
+	}
+	if len(events) == 0 {
+		return
+	}
+
+	_, subs := h.requirements()
+	var targets []*subscription
+	for _, sub := range subs {
+		if sub.settings.ListingAlerts {
+			targets = append(targets, sub)
+		}
+	}
+	h.publish(targets, &MarketSnapshot{
+		Time:     time.Now(),
+		Exchange: h.md.Name(),
+		Listings: events,
+	})
+}
+
+// dropSymbols забывает всё, что хаб и мониторы пользователей накопили по символам
+func (h *Hub) dropSymbols(symbols []string) {
+	if len(symbols) == 0 {
+		return
+	}
+
+	h.liveMu.Lock()
+	for tf := range h.live {
+		for _, sym := range symbols {
+			delete(h.live[tf], sym)
+			delete(h.dirty[tf], sym)
+		}
+	}
+	for _, sym := range symbols {
+		delete(h.marks, sym)
+	}
+	h.liveMu.Unlock()
+
+	h.positioningMu.Lock()
+	for _, sym := range symbols {
+		delete(h.positioning, sym)
+	}
+	h.positioningMu.Unlock()
+
+	h.oiMu.Lock()
+	for _, sym := range symbols {
+		delete(h.oiHistory, exchanges.CanonicalSymbol(sym))
+	}
+	h.oiMu.Unlock()
+}
+
+// restartKlineStreams переподписывает стримы свечей на новый список символов.
+// Пропущенные за время переподключения свечи стрим догружает сам.
+func (h *Hub) restartKlineStreams(ctx context.Context) {
+	if h.stream == nil {
+		return
+	}
+	h.liveMu.Lock()
+	defer h.liveMu.Unlock()
+	for tf, stop := range h.streamStop {
+		stop()
+		h.startKlineStream(ctx, tf)
+	}
+}
@@ -0,0 +1,59 @@
+package persistence
+
+import (
+	"time"
+
+	"1333/internal/bots"
+)
+
+// universeFilter помнит, какие тикеры не проходят фильтры ликвидности пользователя.
+// Решение пересчитывается по снапшотам REST-тика и применяется ко всем снапшотам,
+// включая стриминговые, в которых нет объёма за 24ч и OI.
+type universeFilter struct {
+	s          bots.UserSettings
+	excluded   map[string]bool
+	oiNotional map[string]float64 // последний известный OI в USDT: OI есть не в каждом тике
+}
+
+func newUniverseFilter(s bots.UserSettings) *universeFilter {
+	return &universeFilter{
+		s:          s,
+		excluded:   make(map[string]bool),
+		oiNotional: make(map[string]float64),
+	}
+}
+
+// refresh пересчитывает исключённые тикеры. Критерий, по которому у площадки
+// нет данных, тикер не отсекает.
+func (u *universeFilter) refresh(snap *MarketSnapshot) {
+	if !u.s.HasUniverseFilters() || !snap.Full {
+		return
+	}
+	minAge := time.Duration(u.s.MinListingAge) * 24 * time.Hour
+	for sym, ss := range snap.Symbols {
+		if ss.OI > 0 && ss.Price > 0 {
+			u.oiNotional[sym] = ss.OI * ss.Price
+		}
+
+		excluded := false
+		if u.s.MinQuoteVolume > 0 && ss.QuoteVolume24h > 0 && ss.QuoteVolume24h < u.s.MinQuoteVolume {
+			excluded = true
+		}
+		if oi, ok := u.oiNotional[sym]; ok && u.s.MinOINotional > 0 && oi < u.s.MinOINotional {
+			excluded = true
+		}
+		if minAge > 0 && !ss.ListedAt.IsZero() && snap.Time.Sub(ss.ListedAt) < minAge {
+			excluded = true
+		}
+
+		if excluded {
+			u.excluded[sym] = true
+		} else {
+			delete(u.excluded, sym)
+		}
+	}
+}
+
+func (u *universeFilter) allows(sym string) bool {
+	return !u.excluded[sym]
+}